@@ -0,0 +1,133 @@
+package toolmodel
+
+import (
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolBuilder assembles a Tool step by step. Create one with NewTool, chain
+// the With and Add methods, and finish with Build:
+//
+//	tool, err := toolmodel.NewTool("search").
+//		WithDescription("Search documents").
+//		WithNamespace("docs").
+//		AddStringParam("query", "the query", true).
+//		AddIntParam("limit", "max results", false).
+//		Build()
+//
+// A ToolBuilder is not safe for concurrent use.
+type ToolBuilder struct {
+	tool       Tool
+	properties map[string]any
+	required   []any
+	noParams   bool
+	err        error
+}
+
+// NewTool starts building a tool named name.
+func NewTool(name string) *ToolBuilder {
+	return &ToolBuilder{
+		tool:       Tool{Tool: mcp.Tool{Name: name}},
+		properties: make(map[string]any),
+	}
+}
+
+// WithTitle sets the tool's human-facing Title.
+func (b *ToolBuilder) WithTitle(title string) *ToolBuilder {
+	b.tool.Title = title
+	return b
+}
+
+// WithDescription sets the tool's Description.
+func (b *ToolBuilder) WithDescription(description string) *ToolBuilder {
+	b.tool.Description = description
+	return b
+}
+
+// WithNamespace sets the tool's Namespace.
+func (b *ToolBuilder) WithNamespace(namespace string) *ToolBuilder {
+	b.tool.Namespace = namespace
+	return b
+}
+
+// WithVersion sets the tool's Version.
+func (b *ToolBuilder) WithVersion(version string) *ToolBuilder {
+	b.tool.Version = version
+	return b
+}
+
+// WithTags appends tags to the tool's Tags. Tags are stored as given; use
+// NormalizeTags to clean them.
+func (b *ToolBuilder) WithTags(tags ...string) *ToolBuilder {
+	b.tool.Tags = append(b.tool.Tags, tags...)
+	return b
+}
+
+// NoParameters declares that the tool takes no parameters, so Build emits
+// NoParametersSchema. Declaring a parameter as well makes Build fail.
+func (b *ToolBuilder) NoParameters() *ToolBuilder {
+	b.noParams = true
+	return b
+}
+
+// AddStringParam declares a string input property.
+func (b *ToolBuilder) AddStringParam(name, description string, required bool) *ToolBuilder {
+	return b.AddParam(name, map[string]any{"type": "string"}, description, required)
+}
+
+// AddIntParam declares an integer input property.
+func (b *ToolBuilder) AddIntParam(name, description string, required bool) *ToolBuilder {
+	return b.AddParam(name, map[string]any{"type": "integer"}, description, required)
+}
+
+// AddParam declares an input property with the given subschema, for types
+// not covered by the typed helpers. A non-empty description is set on a copy
+// of schema. Declaring the same property twice makes Build fail.
+func (b *ToolBuilder) AddParam(name string, schema map[string]any, description string, required bool) *ToolBuilder {
+	if _, ok := b.properties[name]; ok {
+		if b.err == nil {
+			b.err = fmt.Errorf("%w: parameter %q declared twice", ErrInvalidTool, name)
+		}
+		return b
+	}
+	prop := cloneJSONValue(schema).(map[string]any)
+	if prop == nil {
+		prop = map[string]any{}
+	}
+	if description != "" {
+		prop["description"] = description
+	}
+	b.properties[name] = prop
+	if required {
+		b.required = append(b.required, name)
+	}
+	return b
+}
+
+// Build assembles the InputSchema, an object schema with the declared
+// properties and required list, and returns the tool after Validate. A tool
+// with no declared properties gets NoParametersSchema. Each call returns a new
+// Tool that shares nothing with the builder.
+func (b *ToolBuilder) Build() (*Tool, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.noParams && len(b.properties) > 0 {
+		return nil, fmt.Errorf("%w: parameters declared on a tool with NoParameters", ErrInvalidTool)
+	}
+	schema := NoParametersSchema()
+	if len(b.properties) > 0 {
+		delete(schema, "additionalProperties")
+		schema["properties"] = cloneJSONValue(b.properties)
+	}
+	if len(b.required) > 0 {
+		schema["required"] = cloneJSONValue(b.required)
+	}
+	tool := b.tool.Clone()
+	tool.InputSchema = schema
+	if err := tool.Validate(); err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
@@ -0,0 +1,104 @@
+package toolmodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestToolBuilder(t *testing.T) {
+	tool, err := NewTool("search").
+		WithDescription("Search documents").
+		WithNamespace("docs").
+		WithVersion("1.0.0").
+		AddStringParam("query", "the query", true).
+		AddIntParam("limit", "max results", false).
+		AddParam("lang", map[string]any{"type": "string", "enum": []any{"en", "fr"}}, "", false).
+		WithTags("search", "discovery").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if tool.ToolID() != "docs:search" || tool.Version != "1.0.0" || tool.Description != "Search documents" {
+		t.Errorf("Build() = %+v", tool)
+	}
+	if want := []string{"search", "discovery"}; !reflect.DeepEqual(tool.Tags, want) {
+		t.Errorf("Tags = %v, want %v", tool.Tags, want)
+	}
+	wantSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string", "description": "the query"},
+			"limit": map[string]any{"type": "integer", "description": "max results"},
+			"lang":  map[string]any{"type": "string", "enum": []any{"en", "fr"}},
+		},
+		"required": []any{"query"},
+	}
+	if !reflect.DeepEqual(tool.InputSchema, wantSchema) {
+		t.Errorf("InputSchema = %v, want %v", tool.InputSchema, wantSchema)
+	}
+
+	v := NewDefaultValidator()
+	if err := v.ValidateInput(tool, map[string]any{"query": "go", "limit": 5}); err != nil {
+		t.Errorf("ValidateInput() error = %v", err)
+	}
+	if err := v.ValidateInput(tool, map[string]any{"limit": 5}); err == nil {
+		t.Error("ValidateInput() without required query error = nil")
+	}
+}
+
+func TestToolBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ToolBuilder
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "no parameters", builder: NewTool("ping"), want: NoParametersSchema()},
+		{name: "explicit no parameters", builder: NewTool("ping").NoParameters(), want: NoParametersSchema()},
+		{
+			name:    "no parameters with a parameter",
+			builder: NewTool("t").NoParameters().AddStringParam("q", "", true),
+			wantErr: true,
+		},
+		{name: "empty name", builder: NewTool(""), wantErr: true},
+		{name: "invalid name", builder: NewTool("has space"), wantErr: true},
+		{
+			name:    "duplicate parameter",
+			builder: NewTool("t").AddStringParam("q", "", true).AddIntParam("q", "", false),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, err := tt.builder.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidTool) {
+					t.Errorf("Build() error = %v, want ErrInvalidTool", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(tool.InputSchema, tt.want) {
+				t.Errorf("InputSchema = %v, want %v", tool.InputSchema, tt.want)
+			}
+		})
+	}
+
+	b := NewTool("t").AddStringParam("q", "", true)
+	first, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	first.InputSchema.(map[string]any)["properties"].(map[string]any)["q"].(map[string]any)["type"] = "integer"
+	second, err := b.AddIntParam("n", "", false).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if got := second.InputSchema.(map[string]any)["properties"].(map[string]any)["q"].(map[string]any)["type"]; got != "string" {
+		t.Errorf("second Build() shares schema with the first: q type = %v", got)
+	}
+}
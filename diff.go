@@ -0,0 +1,161 @@
+package toolmodel
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// FieldChange is a single difference between two tools reported by Diff.
+type FieldChange struct {
+	// Path names the changed field in dotted form using JSON field names,
+	// e.g. "description" or "inputSchema.properties.limit.default". Array
+	// elements are addressed by index ("inputSchema.required.0").
+	Path string
+	// Old is the value in the first tool, or nil if the field was added.
+	Old any
+	// New is the value in the second tool, or nil if the field was removed.
+	New any
+}
+
+// Diff reports how b differs from a, for logging changes between registry
+// syncs. It compares Name, Description, Namespace and Version; the
+// normalized tags, reporting each removed tag as {Path: "tags", Old: tag}
+// and each added tag as {Path: "tags", New: tag}; and the input and output
+// schema trees. Schemas are compared in canonical form (see
+// CanonicalizeSchema), so representation, key order and 10 vs 10.0 do not
+// count as changes; arrays of the same length are compared element by
+// element, other array changes are reported whole. A schema that cannot be
+// canonicalized is compared as a whole. A nil tool is treated as an empty
+// one. Changes are returned in a deterministic order and Diff returns nil
+// when the tools do not differ.
+func Diff(a, b *Tool) []FieldChange {
+	if a == nil {
+		a = &Tool{}
+	}
+	if b == nil {
+		b = &Tool{}
+	}
+	var changes []FieldChange
+	for _, f := range []struct {
+		path     string
+		old, new string
+	}{
+		{"name", a.Name, b.Name},
+		{"description", a.Description, b.Description},
+		{"namespace", a.Namespace, b.Namespace},
+		{"version", a.Version, b.Version},
+	} {
+		if f.old != f.new {
+			changes = append(changes, FieldChange{Path: f.path, Old: f.old, New: f.new})
+		}
+	}
+	changes = append(changes, diffTags(a.Tags, b.Tags)...)
+	changes = append(changes, diffSchema("inputSchema", a.InputSchema, b.InputSchema)...)
+	changes = append(changes, diffSchema("outputSchema", a.OutputSchema, b.OutputSchema)...)
+	return changes
+}
+
+// diffTags reports removed then added tags, each in sorted order.
+func diffTags(old, new []string) []FieldChange {
+	oldTags, newTags := sortedTags(old), sortedTags(new)
+	inOld := make(map[string]bool, len(oldTags))
+	for _, tag := range oldTags {
+		inOld[tag] = true
+	}
+	inNew := make(map[string]bool, len(newTags))
+	for _, tag := range newTags {
+		inNew[tag] = true
+	}
+	var changes []FieldChange
+	for _, tag := range oldTags {
+		if !inNew[tag] {
+			changes = append(changes, FieldChange{Path: "tags", Old: tag})
+		}
+	}
+	for _, tag := range newTags {
+		if !inOld[tag] {
+			changes = append(changes, FieldChange{Path: "tags", New: tag})
+		}
+	}
+	return changes
+}
+
+// diffSchema compares two schemas rooted at path.
+func diffSchema(path string, old, new any) []FieldChange {
+	oldPresent, newPresent := schemaPresent(old), schemaPresent(new)
+	if !oldPresent && !newPresent {
+		return nil
+	}
+	var oldMap, newMap map[string]any
+	var oldErr, newErr error
+	if oldPresent {
+		oldMap, oldErr = CanonicalizeSchema(old)
+	}
+	if newPresent {
+		newMap, newErr = CanonicalizeSchema(new)
+	}
+	if oldErr != nil || newErr != nil {
+		if schemasEqual(old, new) {
+			return nil
+		}
+		return []FieldChange{{Path: path, Old: presentOrNil(old, oldPresent), New: presentOrNil(new, newPresent)}}
+	}
+	if !oldPresent || !newPresent {
+		return []FieldChange{{Path: path, Old: mapOrNil(oldMap), New: mapOrNil(newMap)}}
+	}
+	return diffValues(path, oldMap, newMap, nil)
+}
+
+// diffValues appends the differences between two canonical JSON values.
+func diffValues(path string, old, new any, changes []FieldChange) []FieldChange {
+	switch o := old.(type) {
+	case map[string]any:
+		n, ok := new.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(o) {
+			child := path + "." + key
+			if nv, ok := n[key]; ok {
+				changes = diffValues(child, o[key], nv, changes)
+			} else {
+				changes = append(changes, FieldChange{Path: child, Old: o[key]})
+			}
+		}
+		for _, key := range sortedKeys(n) {
+			if _, ok := o[key]; !ok {
+				changes = append(changes, FieldChange{Path: path + "." + key, New: n[key]})
+			}
+		}
+		return changes
+	case []any:
+		n, ok := new.([]any)
+		if !ok || len(n) != len(o) {
+			break
+		}
+		for i := range o {
+			changes = diffValues(path+"."+strconv.Itoa(i), o[i], n[i], changes)
+		}
+		return changes
+	}
+	if !reflect.DeepEqual(old, new) {
+		changes = append(changes, FieldChange{Path: path, Old: old, New: new})
+	}
+	return changes
+}
+
+func presentOrNil(schema any, present bool) any {
+	if !present {
+		return nil
+	}
+	return schema
+}
+
+// mapOrNil turns a nil map into an untyped nil, so FieldChange.Old and New
+// compare equal to nil when a schema was added or removed.
+func mapOrNil(m map[string]any) any {
+	if m == nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,89 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestDiff(t *testing.T) {
+	before := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Description: "Search documents",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"q":     map[string]any{"type": "string"},
+					"limit": map[string]any{"type": "integer", "default": 10},
+					"sort":  map[string]any{"type": "string"},
+				},
+				"required": []any{"q"},
+			},
+		},
+		Namespace: "docs",
+		Version:   "1.0.0",
+		Tags:      []string{"search", "legacy"},
+	}
+	after := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Description: "Search all documents",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"q": {"type": "string"},
+					"limit": {"type": "integer", "default": 20},
+					"lang": {"type": "string"}
+				},
+				"required": ["lang"]
+			}`),
+			OutputSchema: map[string]any{"type": "object"},
+		},
+		Namespace: "docs",
+		Version:   "1.1.0",
+		Tags:      []string{"Search", "discovery"},
+	}
+
+	want := []FieldChange{
+		{Path: "description", Old: "Search documents", New: "Search all documents"},
+		{Path: "version", Old: "1.0.0", New: "1.1.0"},
+		{Path: "tags", Old: "legacy"},
+		{Path: "tags", New: "discovery"},
+		{Path: "inputSchema.properties.limit.default", Old: int64(10), New: int64(20)},
+		{Path: "inputSchema.properties.sort", Old: map[string]any{"type": "string"}},
+		{Path: "inputSchema.properties.lang", New: map[string]any{"type": "string"}},
+		{Path: "inputSchema.required.0", Old: "q", New: "lang"},
+		{Path: "outputSchema", New: map[string]any{"type": "object"}},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() =\n%v\nwant\n%v", got, want)
+	}
+
+	t.Run("equivalent representations", func(t *testing.T) {
+		raw := before.Clone()
+		raw.InputSchema = json.RawMessage(`{"required":["q"],"type":"object","properties":{"sort":{"type":"string"},"q":{"type":"string"},"limit":{"default":10.0,"type":"integer"}}}`)
+		raw.Tags = []string{"legacy", "SEARCH"}
+		if got := Diff(before, raw); got != nil {
+			t.Errorf("Diff() = %v, want nil", got)
+		}
+	})
+
+	t.Run("nil tool", func(t *testing.T) {
+		got := Diff(nil, &Tool{Tool: mcp.Tool{Name: "t"}})
+		if want := []FieldChange{{Path: "name", Old: "", New: "t"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Diff(nil, t) = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("boolean schema", func(t *testing.T) {
+		a := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: json.RawMessage("true")}}
+		b := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: map[string]any{"type": "object"}}}
+		got := Diff(a, b)
+		if len(got) != 1 || got[0].Path != "inputSchema" {
+			t.Errorf("Diff() = %v, want one inputSchema change", got)
+		}
+	})
+}
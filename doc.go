@@ -12,6 +12,7 @@
 //   - JSON Schema validation helpers for inputs and outputs
 //   - JSON serialization compatible with the MCP Tool spec
 //   - Tag normalization helpers for discovery layers
+//   - ToolSet, a concurrency-safe catalog keyed by tool ID
 //
 // The package enforces MCP schema rules by default:
 //
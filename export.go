@@ -0,0 +1,168 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Export targets understood by NameValidFor.
+const (
+	TargetOpenAI    = "openai"
+	TargetAnthropic = "anthropic"
+	TargetGemini    = "gemini"
+	TargetMCP       = "mcp"
+)
+
+// nameRules describes the function-name constraints of an export target.
+type nameRules struct {
+	maxLen int
+	// validRune reports whether r may appear in the name.
+	validRune func(r rune) bool
+	// validFirst, when set, constrains the first character.
+	validFirst func(r rune) bool
+	// charset describes validRune for error reasons.
+	charset string
+	// bareName checks Name instead of the ToolID, for targets that export
+	// the name without its namespace.
+	bareName bool
+}
+
+func asciiAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+var targetNameRules = map[string]nameRules{
+	// OpenAI and Anthropic share ^[a-zA-Z0-9_-]{1,64}$.
+	TargetOpenAI: {
+		maxLen:    64,
+		validRune: func(r rune) bool { return asciiAlnum(r) || r == '_' || r == '-' },
+		charset:   "[a-zA-Z0-9_-]",
+	},
+	TargetAnthropic: {
+		maxLen:    64,
+		validRune: func(r rune) bool { return asciiAlnum(r) || r == '_' || r == '-' },
+		charset:   "[a-zA-Z0-9_-]",
+	},
+	// Gemini additionally allows dots and colons but requires a leading
+	// letter or underscore.
+	TargetGemini: {
+		maxLen: 64,
+		validRune: func(r rune) bool {
+			return asciiAlnum(r) || r == '_' || r == '-' || r == '.' || r == ':'
+		},
+		validFirst: func(r rune) bool {
+			return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		},
+		charset: "[a-zA-Z0-9_.:-]",
+	},
+	// ToMCPJSON emits the bare Name; the namespace is not part of it.
+	TargetMCP: {
+		maxLen:    maxToolNameLen,
+		validRune: validToolNameRune,
+		charset:   "[a-zA-Z0-9_.-]",
+		bareName:  true,
+	},
+}
+
+// NameValidFor reports whether the name the tool is exported under satisfies
+// the function-name rules of the given export target ("openai", "anthropic",
+// "gemini", or "mcp"). When it does not, the returned reason explains why.
+// For "mcp" that is the bare Name, as emitted by ToMCPJSON; for the other
+// targets it is the ToolID, so a namespaced tool is checked with its
+// namespace.
+func (t *Tool) NameValidFor(target string) (bool, string) {
+	rules, ok := targetNameRules[strings.ToLower(target)]
+	if !ok {
+		return false, fmt.Sprintf("unknown target %q", target)
+	}
+	name := t.ToolID()
+	if rules.bareName {
+		name = t.Name
+	}
+	if name == "" {
+		return false, "name is empty"
+	}
+	if len(name) > rules.maxLen {
+		return false, fmt.Sprintf("name %q exceeds %d characters", name, rules.maxLen)
+	}
+	for i, r := range name {
+		if i == 0 && rules.validFirst != nil && !rules.validFirst(r) {
+			return false, fmt.Sprintf("name %q must not start with %q", name, r)
+		}
+		if !rules.validRune(r) {
+			return false, fmt.Sprintf("name %q contains %q; %s allows only %s", name, r, target, rules.charset)
+		}
+	}
+	return true, ""
+}
+
+// editorDescriptor is the tool descriptor consumed by editor (VS Code / LSP)
+// integrations.
+type editorDescriptor struct {
+	ID     string          `json:"id"`
+	Label  string          `json:"label"`
+	Detail string          `json:"detail"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// ToEditorDescriptor serializes the tool as an editor tool descriptor,
+// {"id","label","detail","schema"}, mapping ToolID to id, DisplayName to
+// label, Description to detail, and InputSchema to schema. A missing
+// InputSchema is emitted as {}; a raw-byte schema keeps its key order.
+func (t *Tool) ToEditorDescriptor() ([]byte, error) {
+	schema, err := exportInputSchema(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(editorDescriptor{
+		ID:     t.ToolID(),
+		Label:  t.DisplayName(),
+		Detail: t.Description,
+		Schema: schema,
+	})
+}
+
+// langChainTool mirrors langchaingo's llms.Tool with a function definition.
+type langChainTool struct {
+	Type     string              `json:"type"`
+	Function langChainDefinition `json:"function"`
+}
+
+type langChainDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToLangChainSpec serializes the tool in the shape of a LangChain-Go
+// llms.Tool:
+//
+//	{"type":"function","function":{"name":...,"description":...,"parameters":...}}
+//
+// name is the ToolID, description is Description, and parameters (the args
+// schema) is InputSchema. A missing InputSchema is emitted as {}; a raw-byte
+// schema keeps its key order.
+func (t *Tool) ToLangChainSpec() ([]byte, error) {
+	schema, err := exportInputSchema(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(langChainTool{
+		Type: "function",
+		Function: langChainDefinition{
+			Name:        t.ToolID(),
+			Description: t.Description,
+			Parameters:  schema,
+		},
+	})
+}
+
+// exportInputSchema encodes an input schema for an export format, using {}
+// when it is absent and preserving the key order of raw-byte schemas.
+func exportInputSchema(schema any) (json.RawMessage, error) {
+	if !schemaPresent(schema) {
+		return json.RawMessage("{}"), nil
+	}
+	return StableJSON(schema, true)
+}
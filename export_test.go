@@ -0,0 +1,111 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestTool_NameValidFor(t *testing.T) {
+	namespaced := &Tool{Tool: mcp.Tool{Name: "search"}, Namespace: "docs"}
+	long := &Tool{Tool: mcp.Tool{Name: strings.Repeat("a", 65)}}
+	plain := &Tool{Tool: mcp.Tool{Name: "search_docs"}}
+
+	tests := []struct {
+		target string
+		tool   *Tool
+		want   bool
+	}{
+		{TargetOpenAI, plain, true},
+		{TargetOpenAI, namespaced, false},
+		{TargetOpenAI, long, false},
+		{TargetAnthropic, plain, true},
+		{TargetAnthropic, namespaced, false},
+		{TargetAnthropic, long, false},
+		{TargetGemini, plain, true},
+		{TargetGemini, namespaced, true},
+		{TargetGemini, long, false},
+		{TargetGemini, &Tool{Tool: mcp.Tool{Name: "1search"}}, false},
+		{TargetMCP, plain, true},
+		{TargetMCP, namespaced, true},
+		{TargetMCP, &Tool{Tool: mcp.Tool{Name: "search:docs"}, Namespace: "docs"}, false},
+		{TargetMCP, long, true},
+		{"unknown", plain, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, reason := tt.tool.NameValidFor(tt.target)
+			if got != tt.want {
+				t.Fatalf("NameValidFor(%q) = %v (%s), want %v", tt.target, got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("NameValidFor() returned no reason for an invalid name")
+			}
+		})
+	}
+}
+
+func TestTool_ToEditorDescriptor(t *testing.T) {
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Title:       "Search Docs",
+			Description: "Full-text search",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`),
+		},
+		Namespace: "docs",
+	}
+	data, err := tool.ToEditorDescriptor()
+	if err != nil {
+		t.Fatalf("ToEditorDescriptor() error = %v", err)
+	}
+	want := `{"id":"docs:search","label":"Search Docs","detail":"Full-text search",` +
+		`"schema":{"type":"object","properties":{"q":{"type":"string"}}}}`
+	if string(data) != want {
+		t.Errorf("ToEditorDescriptor() = %s, want %s", data, want)
+	}
+
+	bare := &Tool{Tool: mcp.Tool{Name: "ping"}}
+	data, err = bare.ToEditorDescriptor()
+	if err != nil {
+		t.Fatalf("ToEditorDescriptor() nil schema error = %v", err)
+	}
+	if want := `{"id":"ping","label":"ping","detail":"","schema":{}}`; string(data) != want {
+		t.Errorf("ToEditorDescriptor() nil schema = %s, want %s", data, want)
+	}
+}
+
+func TestTool_ToLangChainSpec(t *testing.T) {
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Title:       "Search Docs",
+			Description: "Full-text search",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"q": map[string]any{"type": "string"}},
+			},
+		},
+		Namespace: "docs",
+	}
+	data, err := tool.ToLangChainSpec()
+	if err != nil {
+		t.Fatalf("ToLangChainSpec() error = %v", err)
+	}
+	want := `{"type":"function","function":{"name":"docs:search","description":"Full-text search",` +
+		`"parameters":{"properties":{"q":{"type":"string"}},"type":"object"}}}`
+	if string(data) != want {
+		t.Errorf("ToLangChainSpec() = %s, want %s", data, want)
+	}
+
+	bare := &Tool{Tool: mcp.Tool{Name: "ping"}}
+	data, err = bare.ToLangChainSpec()
+	if err != nil {
+		t.Fatalf("ToLangChainSpec() nil schema error = %v", err)
+	}
+	if want := `{"type":"function","function":{"name":"ping","description":"","parameters":{}}}`; string(data) != want {
+		t.Errorf("ToLangChainSpec() nil schema = %s, want %s", data, want)
+	}
+}
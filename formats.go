@@ -0,0 +1,103 @@
+package toolmodel
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// standardFormats assert the JSON Schema formats enabled by
+// WithFormatAssertion.
+var standardFormats = map[string]func(string) error{
+	"email":     checkEmailFormat,
+	"uri":       checkURIFormat,
+	"date-time": checkDateTimeFormat,
+	"ipv4":      checkIPv4Format,
+	"ipv6":      checkIPv6Format,
+	"uuid":      checkUUIDFormat,
+	"hostname":  checkHostnameFormat,
+}
+
+var (
+	uuidPattern      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+)
+
+// checkEmailFormat accepts a bare RFC 5322 address such as "ada@example.com",
+// without a display name or angle brackets.
+func checkEmailFormat(s string) error {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return err
+	}
+	if addr.Address != s {
+		return errors.New("not a bare address")
+	}
+	return nil
+}
+
+// checkURIFormat accepts an absolute URI, i.e. one with a scheme.
+func checkURIFormat(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" {
+		return errors.New("missing scheme")
+	}
+	return nil
+}
+
+// checkDateTimeFormat accepts an RFC 3339 date-time.
+func checkDateTimeFormat(s string) error {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err
+}
+
+func checkIPv4Format(s string) error {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return err
+	}
+	if !addr.Is4() {
+		return errors.New("not an IPv4 address")
+	}
+	return nil
+}
+
+func checkIPv6Format(s string) error {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return err
+	}
+	if !addr.Is6() || addr.Zone() != "" {
+		return errors.New("not an IPv6 address")
+	}
+	return nil
+}
+
+func checkUUIDFormat(s string) error {
+	if !uuidPattern.MatchString(s) {
+		return errors.New("not of the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx")
+	}
+	return nil
+}
+
+// checkHostnameFormat accepts an RFC 1123 host name: dot-separated labels of
+// 1 to 63 letters, digits and inner hyphens, at most 253 characters overall.
+func checkHostnameFormat(s string) error {
+	if s == "" || len(s) > 253 {
+		return errors.New("must be 1 to 253 characters")
+	}
+	for _, label := range strings.Split(s, ".") {
+		if len(label) > 63 || !hostLabelPattern.MatchString(label) {
+			return fmt.Errorf("invalid label %q", label)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ go 1.24.4
 require (
 	github.com/google/jsonschema-go v0.3.0
 	github.com/modelcontextprotocol/go-sdk v1.2.0
+	google.golang.org/protobuf v1.36.12
 )
 
 require (
@@ -0,0 +1,359 @@
+package toolmodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LintRule identifies a schema lint rule.
+type LintRule string
+
+// Lint rules reported by LintSchema.
+const (
+	// RulePermissiveInputSchema flags input schemas that accept any value,
+	// such as {}, true, or an object schema without constraints.
+	RulePermissiveInputSchema LintRule = "PermissiveInputSchema"
+	// RuleStructuredOutputMismatch flags an OutputSchema that cannot describe
+	// MCP structured output (see LintTool).
+	RuleStructuredOutputMismatch LintRule = "StructuredOutputMismatch"
+	// RuleInvalidDefault flags a top-level property whose "default" does not
+	// satisfy the property's own subschema.
+	RuleInvalidDefault LintRule = "InvalidDefault"
+	// RuleInvalidExample flags an entry of the schema's top-level "examples"
+	// that does not validate against the schema.
+	RuleInvalidExample LintRule = "InvalidExample"
+)
+
+// LintIssue is a single advisory finding from a lint rule.
+// Lint issues never make a schema invalid; they highlight risky constructs.
+type LintIssue struct {
+	// Rule is the rule that produced the issue.
+	Rule LintRule
+	// Path is a JSON Pointer to the offending schema node ("" for the root).
+	Path string
+	// Message describes the issue.
+	Message string
+}
+
+// String formats the issue as "Rule at path: message".
+func (i LintIssue) String() string {
+	path := i.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s at %s: %s", i.Rule, path, i.Message)
+}
+
+// LintSchema checks an input schema for risky or questionable constructs and
+// returns the issues found. It returns an error only when the schema cannot be
+// parsed. Boolean schemas (true/false) are accepted in addition to the usual
+// representations.
+func LintSchema(schema any) ([]LintIssue, error) {
+	permissive, err := SchemaAcceptsAnything(schema)
+	if err != nil {
+		return nil, err
+	}
+	var issues []LintIssue
+	if permissive {
+		issues = append(issues, permissiveIssue())
+	}
+	if _, ok := booleanSchema(schema); ok {
+		return issues, nil
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, lintDefaults(m)...)
+	issues = append(issues, lintExamples(m)...)
+	return issues, nil
+}
+
+// lintExamples validates each top-level "examples" entry against the whole
+// schema. Schemas the DefaultValidator would reject are skipped.
+func lintExamples(schema map[string]any) []LintIssue {
+	examples, _ := schema["examples"].([]any)
+	v := NewDefaultValidator()
+	var issues []LintIssue
+	for i, example := range examples {
+		violations, err := v.violations(schema, example)
+		if err != nil {
+			return nil
+		}
+		if len(violations) > 0 {
+			issues = append(issues, LintIssue{
+				Rule:    RuleInvalidExample,
+				Path:    "/examples/" + strconv.Itoa(i),
+				Message: "example does not validate against the schema: " + violations[0].Error(),
+			})
+		}
+	}
+	return issues
+}
+
+// lintDefaults checks the "default" of each top-level property against that
+// property's subschema, resolving references against the whole schema.
+// Schemas the DefaultValidator would reject are skipped; ValidateSchema
+// reports those.
+func lintDefaults(schema map[string]any) []LintIssue {
+	props := schemaProperties(schema)
+	names := make([]string, 0, len(props))
+	for name, raw := range props {
+		if prop, ok := raw.(map[string]any); ok {
+			if _, ok := prop["default"]; ok {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if _, _, err := NewDefaultValidator().prepare(schema); err != nil {
+		return nil
+	}
+	c, err := newViolationCollector(schema)
+	if err != nil {
+		return nil
+	}
+	sort.Strings(names)
+	var issues []LintIssue
+	for _, name := range names {
+		path := "/properties/" + escapeJSONPointer(name)
+		def := props[name].(map[string]any)["default"]
+		if err := c.check(c.ref(path), def); err != nil {
+			v := violationFromError("", path, err)
+			reason := v.message
+			if v.keyword != "" {
+				reason = v.keyword + ": " + reason
+			}
+			issues = append(issues, LintIssue{
+				Rule:    RuleInvalidDefault,
+				Path:    path + "/default",
+				Message: "default does not satisfy the property schema: " + reason,
+			})
+		}
+	}
+	return issues
+}
+
+// LintTool lints a tool's schemas. Input schema issues are reported as by
+// LintSchema with paths prefixed by "/inputSchema".
+//
+// It also checks that the OutputSchema is consistent with structured output.
+// mcp.Tool has no annotation declaring structured output; declaring an
+// OutputSchema is the declaration. Because MCP structuredContent is always a
+// JSON object, an OutputSchema is consistent when its top-level "type" is
+// "object" or absent. A boolean output schema, or one typed as an array or
+// scalar, is reported as RuleStructuredOutputMismatch.
+func LintTool(tool *Tool) ([]LintIssue, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("%w: tool is nil", ErrInvalidTool)
+	}
+	var issues []LintIssue
+	if schemaPresent(tool.InputSchema) {
+		inputIssues, err := LintSchema(tool.InputSchema)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, prefixIssues("/inputSchema", inputIssues)...)
+	}
+	if tool.HasStructuredOutput() {
+		outputIssues, err := lintOutputSchema(tool.OutputSchema)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, prefixIssues("/outputSchema", outputIssues)...)
+	}
+	return issues, nil
+}
+
+// Inspect runs the hard checks and the advisory lints on t in one call, so a
+// UI can show warnings without failing the tool. errs holds blocking problems:
+// Validate failures and input or output schemas rejected by ValidateSchema.
+// warnings holds the LintTool issues, a missing description, and a note per
+// input format, which the DefaultValidator asserts only when
+// WithFormatAssertion(true) is set (plus WithCustomFormat for non-standard
+// formats).
+func (t *Tool) Inspect() (errs []error, warnings []string) {
+	if err := t.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateToolSchema(t.InputSchema); err != nil {
+		errs = append(errs, fmt.Errorf("inputSchema: %w", err))
+	}
+	if t.HasStructuredOutput() {
+		if err := validateToolSchema(t.OutputSchema); err != nil {
+			errs = append(errs, fmt.Errorf("outputSchema: %w", err))
+		}
+	}
+
+	if strings.TrimSpace(t.Description) == "" {
+		warnings = append(warnings, "description is empty")
+	}
+	if issues, err := LintTool(t); err == nil {
+		for _, issue := range issues {
+			warnings = append(warnings, issue.String())
+		}
+	}
+	if formats, err := t.InputPropertyFormats(); err == nil {
+		names := make([]string, 0, len(formats))
+		for name := range formats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			format := formats[name]
+			if _, ok := standardFormats[format]; ok {
+				warnings = append(warnings, fmt.Sprintf("format %q of input property %q is asserted only with WithFormatAssertion(true)", format, name))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("format %q of input property %q is asserted only with WithFormatAssertion(true) and WithCustomFormat", format, name))
+			}
+		}
+	}
+	return errs, warnings
+}
+
+// validateToolSchema runs ValidateSchema on a present, non-boolean schema.
+// Absent schemas are left to Tool.Validate.
+func validateToolSchema(schema any) error {
+	if !schemaPresent(schema) {
+		return nil
+	}
+	if _, ok := booleanSchema(schema); ok {
+		return nil
+	}
+	return ValidateSchema(schema)
+}
+
+func lintOutputSchema(schema any) ([]LintIssue, error) {
+	if _, ok := booleanSchema(schema); ok {
+		return []LintIssue{{
+			Rule:    RuleStructuredOutputMismatch,
+			Message: "boolean output schema does not describe a structured (object) result",
+		}}, nil
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	if types := schemaTypes(m); types != nil && !schemaAllowsType(m, "object") {
+		return []LintIssue{{
+			Rule:    RuleStructuredOutputMismatch,
+			Message: fmt.Sprintf("output schema type %v cannot describe structuredContent, which is always an object", types),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// prefixIssues prepends prefix to the path of each issue.
+func prefixIssues(prefix string, issues []LintIssue) []LintIssue {
+	for i := range issues {
+		issues[i].Path = prefix + issues[i].Path
+	}
+	return issues
+}
+
+func permissiveIssue() LintIssue {
+	return LintIssue{
+		Rule:    RulePermissiveInputSchema,
+		Message: "schema accepts any input; declare properties or set additionalProperties to false",
+	}
+}
+
+// booleanSchema reports whether schema is a boolean schema and its value.
+func booleanSchema(schema any) (value, ok bool) {
+	var data []byte
+	switch s := schema.(type) {
+	case bool:
+		return s, true
+	case json.RawMessage:
+		data = s
+	case []byte:
+		data = s
+	default:
+		return false, false
+	}
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
+
+// annotationKeywords are schema keywords that never constrain instances.
+var annotationKeywords = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"$anchor":     true,
+	"$defs":       true,
+	"definitions": true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+	"deprecated":  true,
+	"readOnly":    true,
+	"writeOnly":   true,
+	"format":      true,
+}
+
+// SchemaAcceptsAnything reports whether schema accepts any tool arguments:
+// the empty schema {}, the boolean schema true, or an object schema with no
+// constraints whose additionalProperties (and unevaluatedProperties) is not
+// false. Annotations such as "title" or "default" are not constraints, and
+// neither is "type":"object", since tool arguments are always objects. It
+// returns an error only when the schema cannot be parsed. This is the check
+// behind the PermissiveInputSchema lint.
+func SchemaAcceptsAnything(schema any) (bool, error) {
+	if b, ok := booleanSchema(schema); ok {
+		return b, nil
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return false, err
+	}
+	return acceptsAnything(m), nil
+}
+
+// acceptsAnything reports whether an object-form schema places no constraints
+// on tool arguments. A top-level "type":"object" does not count as a
+// constraint because tool arguments are always objects, and
+// additionalProperties/unevaluatedProperties only count when they are not
+// true or {}.
+func acceptsAnything(schema map[string]any) bool {
+	for k, v := range schema {
+		switch {
+		case annotationKeywords[k]:
+		case k == "type":
+			if v != "object" {
+				return false
+			}
+		case k == "additionalProperties" || k == "unevaluatedProperties":
+			if !isTrueSchema(v) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isTrueSchema reports whether v is the boolean schema true or the empty
+// schema {}.
+func isTrueSchema(v any) bool {
+	switch s := v.(type) {
+	case bool:
+		return s
+	case map[string]any:
+		return len(s) == 0
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func hasLintRule(issues []LintIssue, rule LintRule) bool {
+	for _, issue := range issues {
+		if issue.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLintSchema_PermissiveInputSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		want   bool
+	}{
+		{name: "empty object", schema: map[string]any{}, want: true},
+		{name: "boolean true", schema: true, want: true},
+		{name: "raw true", schema: json.RawMessage(" true "), want: true},
+		{name: "boolean false", schema: false, want: false},
+		{
+			name:   "unconstrained object",
+			schema: map[string]any{"type": "object", "additionalProperties": true, "description": "anything"},
+			want:   true,
+		},
+		{name: "bare object type", schema: json.RawMessage(`{"type":"object"}`), want: true},
+		{
+			name:   "no parameters form",
+			schema: map[string]any{"type": "object", "additionalProperties": false},
+			want:   false,
+		},
+		{
+			name: "declared properties",
+			schema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"query": map[string]any{"type": "string"}},
+			},
+			want: false,
+		},
+		{
+			name:   "constrained additionalProperties",
+			schema: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := LintSchema(tt.schema)
+			if err != nil {
+				t.Fatalf("LintSchema() error = %v", err)
+			}
+			if got := hasLintRule(issues, RulePermissiveInputSchema); got != tt.want {
+				t.Errorf("LintSchema() flagged = %v, want %v (issues %v)", got, tt.want, issues)
+			}
+		})
+	}
+}
+
+func TestSchemaAcceptsAnything(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		want   bool
+	}{
+		{"empty object", map[string]any{}, true},
+		{"raw empty object", json.RawMessage(`{}`), true},
+		{"boolean true", true, true},
+		{"raw true", json.RawMessage("true"), true},
+		{"annotated object", map[string]any{"type": "object", "title": "Args", "additionalProperties": map[string]any{}}, true},
+		{"boolean false", false, false},
+		{"closed object", map[string]any{"type": "object", "additionalProperties": false}, false},
+		{"required field", map[string]any{"required": []any{"q"}}, false},
+		{"non-object type", map[string]any{"type": "string"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SchemaAcceptsAnything(tt.schema)
+			if err != nil {
+				t.Fatalf("SchemaAcceptsAnything() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SchemaAcceptsAnything() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := SchemaAcceptsAnything(json.RawMessage(`[1]`)); err == nil {
+		t.Error("SchemaAcceptsAnything() expected error for non-object schema")
+	}
+}
+
+func TestLintSchema_InvalidSchema(t *testing.T) {
+	if _, err := LintSchema(json.RawMessage(`[1,2]`)); err == nil {
+		t.Error("LintSchema() expected error for non-object schema")
+	}
+}
+
+func TestLintSchema_InvalidDefault(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"limit":  map[string]any{"type": "integer", "default": "ten"},
+			"query":  map[string]any{"type": "string", "default": "*"},
+			"sort":   map[string]any{"enum": []any{"asc", "desc"}, "default": "random"},
+			"owner":  map[string]any{"$ref": "#/$defs/user", "default": "@ada"},
+			"status": map[string]any{"type": "string"},
+		},
+		"$defs": map[string]any{
+			"user": map[string]any{"type": "string", "pattern": "^@"},
+		},
+	}
+
+	issues, err := LintSchema(schema)
+	if err != nil {
+		t.Fatalf("LintSchema() error = %v", err)
+	}
+	var paths []string
+	for _, issue := range issues {
+		if issue.Rule == RuleInvalidDefault {
+			paths = append(paths, issue.Path)
+		}
+	}
+	want := []string{"/properties/limit/default", "/properties/sort/default"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("InvalidDefault paths = %v, want %v", paths, want)
+	}
+}
+
+func TestLintSchema_InvalidExample(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"query"},
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string"},
+			"limit": map[string]any{"type": "integer"},
+		},
+		"examples": []any{
+			map[string]any{"query": "golang", "limit": 10},
+			map[string]any{"q": "stale field name"},
+			map[string]any{"query": "rust", "limit": "ten"},
+		},
+	}
+
+	issues, err := LintSchema(schema)
+	if err != nil {
+		t.Fatalf("LintSchema() error = %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		if issue.Rule == RuleInvalidExample {
+			got = append(got, issue.String())
+		}
+	}
+	want := []string{
+		`InvalidExample at /examples/1: example does not validate against the schema: (root): required: missing property "query"`,
+		`InvalidExample at /examples/2: example does not validate against the schema: /limit: type: ten has type "string", want "integer"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("InvalidExample issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	tool := &Tool{Tool: mcp.Tool{Name: "search", InputSchema: schema}}
+	toolIssues, err := LintTool(tool)
+	if err != nil {
+		t.Fatalf("LintTool() error = %v", err)
+	}
+	if !hasLintRule(toolIssues, RuleInvalidExample) || toolIssues[len(toolIssues)-1].Path != "/inputSchema/examples/2" {
+		t.Errorf("LintTool() = %v, want InvalidExample prefixed by /inputSchema", toolIssues)
+	}
+}
+
+func TestLintTool_StructuredOutputMismatch(t *testing.T) {
+	input := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"q": map[string]any{"type": "string"}},
+	}
+	tests := []struct {
+		name   string
+		output any
+		want   bool
+	}{
+		{name: "no output schema", output: nil, want: false},
+		{name: "object output schema", output: map[string]any{"type": "object"}, want: false},
+		{name: "untyped output schema", output: map[string]any{"properties": map[string]any{}}, want: false},
+		{name: "array output schema", output: map[string]any{"type": "array"}, want: true},
+		{name: "scalar output schema", output: json.RawMessage(`{"type":"string"}`), want: true},
+		{name: "boolean output schema", output: json.RawMessage(`true`), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: input, OutputSchema: tt.output}}
+			issues, err := LintTool(tool)
+			if err != nil {
+				t.Fatalf("LintTool() error = %v", err)
+			}
+			if got := hasLintRule(issues, RuleStructuredOutputMismatch); got != tt.want {
+				t.Errorf("LintTool() flagged = %v, want %v (issues %v)", got, tt.want, issues)
+			}
+			for _, issue := range issues {
+				if issue.Rule == RuleStructuredOutputMismatch && issue.Path != "/outputSchema" {
+					t.Errorf("issue path = %q, want /outputSchema", issue.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestLintTool_InputIssuesPrefixed(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: map[string]any{}}}
+	issues, err := LintTool(tool)
+	if err != nil {
+		t.Fatalf("LintTool() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].Rule != RulePermissiveInputSchema || issues[0].Path != "/inputSchema" {
+		t.Errorf("LintTool() = %v, want one PermissiveInputSchema issue at /inputSchema", issues)
+	}
+}
+
+func TestTool_Inspect(t *testing.T) {
+	t.Run("valid with warnings", func(t *testing.T) {
+		tool := &Tool{Tool: mcp.Tool{
+			Name: "fetch",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"url": map[string]any{"type": "string", "format": "uri"},
+				},
+			},
+			OutputSchema: map[string]any{"type": "array"},
+		}}
+		errs, warnings := tool.Inspect()
+		if len(errs) != 0 {
+			t.Fatalf("Inspect() errs = %v, want none", errs)
+		}
+		if len(warnings) != 3 {
+			t.Fatalf("Inspect() warnings = %q, want 3", warnings)
+		}
+		if warnings[0] != "description is empty" {
+			t.Errorf("warnings[0] = %q, want description warning", warnings[0])
+		}
+		if !strings.HasPrefix(warnings[1], "StructuredOutputMismatch at /outputSchema") {
+			t.Errorf("warnings[1] = %q, want StructuredOutputMismatch issue", warnings[1])
+		}
+		if want := `format "uri" of input property "url" is asserted only with WithFormatAssertion(true)`; warnings[2] != want {
+			t.Errorf("warnings[2] = %q, want %q", warnings[2], want)
+		}
+	})
+
+	t.Run("custom format", func(t *testing.T) {
+		tool := &Tool{Tool: mcp.Tool{
+			Name:        "release",
+			Description: "Tags a release",
+			InputSchema: map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"version": map[string]any{"type": "string", "format": "semver"}},
+				"additionalProperties": false,
+			},
+		}}
+		_, warnings := tool.Inspect()
+		want := `format "semver" of input property "version" is asserted only with WithFormatAssertion(true) and WithCustomFormat`
+		if len(warnings) == 0 || warnings[len(warnings)-1] != want {
+			t.Errorf("Inspect() warnings = %q, want last %q", warnings, want)
+		}
+	})
+
+	t.Run("hard errors", func(t *testing.T) {
+		tool := &Tool{Tool: mcp.Tool{
+			Name:        "bad name",
+			Description: "Broken",
+			InputSchema: map[string]any{"$ref": "#/$defs/missing"},
+		}}
+		errs, _ := tool.Inspect()
+		if len(errs) != 2 {
+			t.Fatalf("Inspect() errs = %v, want 2", errs)
+		}
+		if !errors.Is(errs[0], ErrInvalidTool) || !errors.Is(errs[1], ErrInvalidSchema) {
+			t.Errorf("Inspect() errs = %v, want ErrInvalidTool then ErrInvalidSchema", errs)
+		}
+	})
+}
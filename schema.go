@@ -0,0 +1,1255 @@
+package toolmodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// CanonicalizeSchema returns a canonical map form of schema in which JSON
+// numbers are normalized: whole-valued numbers become int64, so a "default" of
+// 10 and 10.0 compare equal. The result does not alias the input.
+func CanonicalizeSchema(schema any) (map[string]any, error) {
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(m).(map[string]any), nil
+}
+
+// CanonicalJSON returns a canonical JSON encoding of schema suitable for
+// hashing and comparison: object keys are sorted and numbers are normalized
+// as by CanonicalizeSchema.
+func CanonicalJSON(schema any) ([]byte, error) {
+	m, err := CanonicalizeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// StableJSON returns a compact JSON encoding of schema in one of two stable
+// forms. With preserveOrder false it is CanonicalJSON: keys sorted and numbers
+// normalized, for hashing. With preserveOrder true, a schema given as raw
+// bytes (json.RawMessage or []byte) keeps the author's key order and number
+// spelling, for display; other representations carry no source order and are
+// encoded with sorted map keys.
+func StableJSON(schema any, preserveOrder bool) ([]byte, error) {
+	if !preserveOrder {
+		return CanonicalJSON(schema)
+	}
+	if _, err := schemaToMap(schema); err != nil {
+		return nil, err
+	}
+	var raw []byte
+	switch s := schema.(type) {
+	case json.RawMessage:
+		raw = s
+	case []byte:
+		raw = s
+	default:
+		return json.Marshal(schema)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// maxSafeInteger is the largest integer that float64 represents exactly.
+const maxSafeInteger = 1 << 53
+
+// normalizeJSONNumbers recursively converts whole-valued numbers to int64 and
+// leaves fractional or out-of-range numbers as float64. A json.Number written
+// as an integer that overflows int64, or that float64 cannot represent at
+// all, is kept as written. Maps and slices are rebuilt, so the result never
+// aliases v.
+func normalizeJSONNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			out[k] = normalizeJSONNumbers(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, e := range val {
+			out[i] = normalizeJSONNumbers(e)
+		}
+		return out
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) <= maxSafeInteger {
+			return int64(val)
+		}
+		return val
+	case float32:
+		return normalizeJSONNumbers(float64(val))
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if !strings.ContainsAny(val.String(), ".eE") {
+			return val
+		}
+		if f, err := val.Float64(); err == nil {
+			return normalizeJSONNumbers(f)
+		}
+		return val
+	case int:
+		return int64(val)
+	case int8:
+		return int64(val)
+	case int16:
+		return int64(val)
+	case int32:
+		return int64(val)
+	case uint8:
+		return int64(val)
+	case uint16:
+		return int64(val)
+	case uint32:
+		return int64(val)
+	default:
+		return v
+	}
+}
+
+// NoParametersSchema returns the MCP-recommended input schema for a tool that
+// takes no parameters: an object that rejects any properties. A new map is
+// returned on each call.
+func NoParametersSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+	}
+}
+
+// InferSchema infers a JSON Schema 2020-12 object schema from example inputs.
+//
+// Property types are widened to accommodate every example: an integer seen in
+// one example and a fractional number in another becomes "number", and
+// otherwise-conflicting types become a list of types. Nested objects and array
+// items are inferred recursively. A property is required when it is present in
+// every example.
+func InferSchema(examples ...map[string]any) (map[string]any, error) {
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("%w: at least one example is required", ErrInvalidSchema)
+	}
+	objs := make([]map[string]any, 0, len(examples))
+	for i, ex := range examples {
+		var normalized map[string]any
+		if err := jsonRoundTrip(ex, &normalized); err != nil {
+			return nil, fmt.Errorf("%w: example %d: %v", ErrInvalidSchema, i, err)
+		}
+		if normalized == nil {
+			normalized = map[string]any{}
+		}
+		objs = append(objs, normalized)
+	}
+	schema := inferObjectSchema(objs)
+	schema["$schema"] = SchemaDialect202012
+	return schema, nil
+}
+
+// inferValueSchema infers a schema accepting all of the given JSON values.
+func inferValueSchema(values []any) map[string]any {
+	types := make(map[string]bool)
+	var objs []map[string]any
+	var items []any
+	for _, v := range values {
+		switch val := v.(type) {
+		case nil:
+			types["null"] = true
+		case bool:
+			types["boolean"] = true
+		case string:
+			types["string"] = true
+		case float64:
+			if val == math.Trunc(val) && !math.IsInf(val, 0) {
+				types["integer"] = true
+			} else {
+				types["number"] = true
+			}
+		case map[string]any:
+			types["object"] = true
+			objs = append(objs, val)
+		case []any:
+			types["array"] = true
+			items = append(items, val...)
+		}
+	}
+	if types["number"] {
+		delete(types, "integer")
+	}
+
+	schema := map[string]any{}
+	if len(objs) > 0 {
+		schema = inferObjectSchema(objs)
+	}
+	if types["array"] && len(items) > 0 {
+		schema["items"] = inferValueSchema(items)
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	switch len(names) {
+	case 0:
+	case 1:
+		schema["type"] = names[0]
+	default:
+		list := make([]any, len(names))
+		for i, name := range names {
+			list[i] = name
+		}
+		schema["type"] = list
+	}
+	return schema
+}
+
+// inferObjectSchema infers an object schema from example objects. Properties
+// present in every object are marked required.
+func inferObjectSchema(objs []map[string]any) map[string]any {
+	valuesByKey := make(map[string][]any)
+	for _, obj := range objs {
+		for k, v := range obj {
+			valuesByKey[k] = append(valuesByKey[k], v)
+		}
+	}
+	keys := make([]string, 0, len(valuesByKey))
+	for k := range valuesByKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	properties := make(map[string]any, len(keys))
+	required := []any{}
+	for _, k := range keys {
+		properties[k] = inferValueSchema(valuesByKey[k])
+		if len(valuesByKey[k]) == len(objs) {
+			required = append(required, k)
+		}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonRoundTrip marshals v and unmarshals the result into out, normalizing Go
+// values into their generic JSON representation.
+func jsonRoundTrip(v any, out any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// schemaToMap converts a schema in any supported representation into a fresh
+// map[string]any. The result never aliases the caller's schema, so it is safe
+// to modify.
+func schemaToMap(schema any) (map[string]any, error) {
+	var data []byte
+	switch s := schema.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: nil schema", ErrInvalidSchema)
+	case map[string]any:
+		if s == nil {
+			return nil, fmt.Errorf("%w: nil schema", ErrInvalidSchema)
+		}
+		var err error
+		if data, err = json.Marshal(s); err != nil {
+			return nil, fmt.Errorf("%w: failed to marshal schema: %v", ErrInvalidSchema, err)
+		}
+	case json.RawMessage:
+		data = s
+	case []byte:
+		data = s
+	case *jsonschema.Schema:
+		if s == nil {
+			return nil, fmt.Errorf("%w: nil schema", ErrInvalidSchema)
+		}
+		var err error
+		if data, err = json.Marshal(s); err != nil {
+			return nil, fmt.Errorf("%w: failed to marshal schema: %v", ErrInvalidSchema, err)
+		}
+	case jsonschema.Schema:
+		var err error
+		if data, err = json.Marshal(&s); err != nil {
+			return nil, fmt.Errorf("%w: failed to marshal schema: %v", ErrInvalidSchema, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected map[string]any or *jsonschema.Schema, got %T", ErrInvalidSchema, schema)
+	}
+	if !rawSchemaPresent(data) {
+		return nil, fmt.Errorf("%w: empty schema", ErrInvalidSchema)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: schema is not a JSON object: %v", ErrInvalidSchema, err)
+	}
+	return m, nil
+}
+
+// schemaProperties returns the "properties" keyword of an object schema, or
+// nil when none are declared.
+func schemaProperties(schema map[string]any) map[string]any {
+	props, _ := schema["properties"].(map[string]any)
+	return props
+}
+
+// schemaRequired returns the "required" keyword of an object schema in
+// declared order, skipping non-string entries.
+func schemaRequired(schema map[string]any) []string {
+	list, _ := schema["required"].([]any)
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if name, ok := v.(string); ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// schemaInt returns an integer-valued keyword of schema, such as "minLength".
+// Fractional and non-numeric values are reported as absent.
+func schemaInt(schema map[string]any, key string) (int, bool) {
+	f, ok := schema[key].(float64)
+	if !ok || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// SchemaMergePatch computes an RFC 7386 JSON Merge Patch that transforms the
+// old schema into the new one. Both schemas may use any supported
+// representation.
+//
+// Merge patches cannot express setting a value to JSON null, so a null value
+// in the new schema (e.g. "default": null) is reported as a removal.
+func SchemaMergePatch(old, new any) ([]byte, error) {
+	oldMap, err := schemaToMap(old)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := schemaToMap(new)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatch(oldMap, newMap))
+}
+
+// ApplySchemaMergePatch applies an RFC 7386 JSON Merge Patch to base and
+// returns the patched schema. base is not modified.
+func ApplySchemaMergePatch(base any, patch []byte) (map[string]any, error) {
+	baseMap, err := schemaToMap(base)
+	if err != nil {
+		return nil, err
+	}
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: invalid merge patch: %v", ErrInvalidSchema, err)
+	}
+	result, ok := applyMergePatch(baseMap, p).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: merge patch does not produce a JSON object", ErrInvalidSchema)
+	}
+	return result, nil
+}
+
+// BreakingChangesError lists the breaking changes found in a malformed schema
+// update; see ValidateSchemaUpdate.
+type BreakingChangesError struct {
+	// Changes describes each change, e.g. `property "q" is newly required`.
+	Changes []string
+}
+
+func (e *BreakingChangesError) Error() string {
+	return "breaking schema changes: " + strings.Join(e.Changes, "; ")
+}
+
+// ValidateSchemaUpdate checks a proposed replacement for the schema old before
+// it is committed. It returns nil whenever new is well-formed according to
+// ValidateSchema, even if the update is breaking; use SchemaUpdateChanges to
+// warn about breaking changes. When new is malformed, the returned error wraps
+// that failure and, when both schemas can still be read, a
+// *BreakingChangesError, so editors can report everything at once.
+func ValidateSchemaUpdate(old, new any) error {
+	changes, err := SchemaUpdateChanges(old, new)
+	if err == nil || len(changes) == 0 {
+		return err
+	}
+	return errors.Join(err, &BreakingChangesError{Changes: changes})
+}
+
+// SchemaUpdateChanges is ValidateSchemaUpdate with the breaking changes
+// returned separately: changes describes each change to the top-level
+// properties that would reject arguments old accepted (removed properties,
+// newly required properties, and changed types), and err is non-nil only when
+// new is malformed. changes is nil when either schema cannot be read.
+func SchemaUpdateChanges(old, new any) (changes []string, err error) {
+	if verr := ValidateSchema(new); verr != nil {
+		err = fmt.Errorf("new schema: %w", verr)
+	}
+	oldMap, oldErr := schemaToMap(old)
+	newMap, newErr := schemaToMap(new)
+	if oldErr == nil && newErr == nil {
+		changes = breakingSchemaChanges(oldMap, newMap)
+	}
+	return changes, err
+}
+
+// breakingSchemaChanges describes changes to the top-level properties of an
+// object schema that would reject arguments old accepted.
+func breakingSchemaChanges(old, new map[string]any) []string {
+	var changes []string
+	oldProps, newProps := schemaProperties(old), schemaProperties(new)
+	for _, name := range sortedKeys(oldProps) {
+		newProp, ok := newProps[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("property %q removed", name))
+			continue
+		}
+		oldSchema, _ := oldProps[name].(map[string]any)
+		newSchema, _ := newProp.(map[string]any)
+		if oldSchema == nil || newSchema == nil {
+			continue
+		}
+		for _, typ := range schemaTypes(oldSchema) {
+			if !schemaAllowsType(newSchema, typ) && !(typ == "integer" && schemaAllowsType(newSchema, "number")) {
+				changes = append(changes, fmt.Sprintf("property %q no longer accepts type %q", name, typ))
+			}
+		}
+	}
+	wasRequired := make(map[string]bool)
+	for _, name := range schemaRequired(old) {
+		wasRequired[name] = true
+	}
+	for _, name := range schemaRequired(new) {
+		if !wasRequired[name] {
+			changes = append(changes, fmt.Sprintf("property %q is newly required", name))
+		}
+	}
+	return changes
+}
+
+// mergePatch returns the merge patch turning old into new.
+func mergePatch(old, new map[string]any) map[string]any {
+	patch := make(map[string]any)
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, nv := range new {
+		ov, ok := old[k]
+		if ok && reflect.DeepEqual(ov, nv) {
+			continue
+		}
+		oldObj, oldIsObj := ov.(map[string]any)
+		newObj, newIsObj := nv.(map[string]any)
+		if ok && oldIsObj && newIsObj {
+			patch[k] = mergePatch(oldObj, newObj)
+			continue
+		}
+		patch[k] = nv
+	}
+	return patch
+}
+
+// applyMergePatch applies patch to target following RFC 7386.
+func applyMergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = applyMergePatch(t[k], v)
+	}
+	return t
+}
+
+// schemaTypes returns the "type" keyword of schema as a list, accepting both
+// the single-string and array forms. It returns nil when no type is declared.
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		out := make([]string, 0, len(t))
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				out = append(out, name)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// schemaAllowsType reports whether schema declares typ, or declares no type
+// at all.
+func schemaAllowsType(schema map[string]any, typ string) bool {
+	types := schemaTypes(schema)
+	if types == nil {
+		return true
+	}
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Keywords whose values are subschemas, grouped by shape.
+var (
+	// singleSchemaKeywords hold one subschema.
+	singleSchemaKeywords = []string{
+		"additionalItems", "additionalProperties", "contains", "else", "if",
+		"items", "not", "propertyNames", "then", "unevaluatedItems",
+		"unevaluatedProperties",
+	}
+	// schemaListKeywords hold an array of subschemas. "items" is included for
+	// the draft-07 tuple form.
+	schemaListKeywords = []string{"allOf", "anyOf", "items", "oneOf", "prefixItems"}
+	// schemaMapKeywords hold an object whose values are subschemas.
+	schemaMapKeywords = []string{
+		"$defs", "definitions", "dependencies", "dependentSchemas",
+		"patternProperties", "properties",
+	}
+)
+
+// walkSchema calls fn for schema and every nested subschema in a deterministic
+// order, passing the JSON Pointer of each node relative to the root. Boolean
+// subschemas are skipped. If fn returns false, the node's children are not
+// visited.
+func walkSchema(schema map[string]any, fn func(path string, node map[string]any) bool) {
+	walkSchemaAt("", schema, fn)
+}
+
+func walkSchemaAt(path string, node map[string]any, fn func(path string, node map[string]any) bool) {
+	if !fn(path, node) {
+		return
+	}
+	for _, kw := range singleSchemaKeywords {
+		if child, ok := node[kw].(map[string]any); ok {
+			walkSchemaAt(path+"/"+kw, child, fn)
+		}
+	}
+	for _, kw := range schemaListKeywords {
+		list, _ := node[kw].([]any)
+		for i, v := range list {
+			if child, ok := v.(map[string]any); ok {
+				walkSchemaAt(path+"/"+kw+"/"+strconv.Itoa(i), child, fn)
+			}
+		}
+	}
+	for _, kw := range schemaMapKeywords {
+		m, _ := node[kw].(map[string]any)
+		for _, name := range sortedKeys(m) {
+			if child, ok := m[name].(map[string]any); ok {
+				walkSchemaAt(path+"/"+kw+"/"+escapeJSONPointer(name), child, fn)
+			}
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// escapeJSONPointer escapes a single JSON Pointer reference token.
+func escapeJSONPointer(token string) string {
+	return jsonPointerEscaper.Replace(token)
+}
+
+// resolveJSONPointer looks up a JSON Pointer (e.g. "/$defs/item") in a
+// decoded JSON document.
+func resolveJSONPointer(doc any, pointer string) (any, bool) {
+	if pointer == "" {
+		return doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	cur := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = jsonPointerUnescaper.Replace(token)
+		switch node := cur.(type) {
+		case map[string]any:
+			next, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// setJSONPointer sets the value at a non-empty JSON Pointer in doc, in place.
+// Missing intermediate objects are created; an array token must be an
+// existing index, or "-" to append. The (possibly new) root is returned,
+// since appending reallocates slices.
+func setJSONPointer(doc any, pointer string, value any) (any, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with \"/\"", pointer)
+	}
+	return setJSONPointerTokens(doc, strings.Split(pointer[1:], "/"), pointer, value)
+}
+
+func setJSONPointerTokens(node any, tokens []string, pointer string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token := jsonPointerUnescaper.Replace(tokens[0])
+	switch n := node.(type) {
+	case map[string]any:
+		child, err := setJSONPointerTokens(n[token], tokens[1:], pointer, value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []any:
+		if token == "-" {
+			child, err := setJSONPointerTokens(nil, tokens[1:], pointer, value)
+			if err != nil {
+				return nil, err
+			}
+			return append(n, child), nil
+		}
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, fmt.Errorf("invalid JSON pointer %q: index %q out of range", pointer, token)
+		}
+		child, err := setJSONPointerTokens(n[i], tokens[1:], pointer, value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	case nil:
+		return setJSONPointerTokens(map[string]any{}, tokens, pointer, value)
+	default:
+		return nil, fmt.Errorf("invalid JSON pointer %q: cannot descend into %T", pointer, node)
+	}
+}
+
+// schemaIndex records every subschema of a schema document by its JSON
+// Pointer, so same-document references can be resolved without walking the
+// document again.
+type schemaIndex struct {
+	root  map[string]any
+	nodes map[string]map[string]any
+	paths []string // sorted
+}
+
+func newSchemaIndex(root map[string]any) *schemaIndex {
+	ix := &schemaIndex{root: root, nodes: make(map[string]map[string]any)}
+	walkSchema(root, func(path string, node map[string]any) bool {
+		ix.nodes[path] = node
+		return true
+	})
+	ix.paths = make([]string, 0, len(ix.nodes))
+	for path := range ix.nodes {
+		ix.paths = append(ix.paths, path)
+	}
+	sort.Strings(ix.paths)
+	return ix
+}
+
+// resolveLocalRef returns the JSON Pointer, from the document root, of the
+// node that the same-document ref at path points to. Fragments are resolved
+// against the nearest enclosing schema resource, i.e. the closest ancestor
+// declaring "$id", or the root. ok is false for references that do not start
+// with "#"; err wraps ErrInvalidSchema when a local reference is malformed or
+// points at nothing.
+func (ix *schemaIndex) resolveLocalRef(path, ref string) (target string, ok bool, err error) {
+	if !strings.HasPrefix(ref, "#") {
+		return "", false, nil
+	}
+	resource := ""
+	for p := path; p != ""; p = p[:strings.LastIndex(p, "/")] {
+		if _, ok := ix.nodes[p]["$id"].(string); ok {
+			resource = p
+			break
+		}
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return "", true, fmt.Errorf("%w: malformed $ref %q at %q", ErrInvalidSchema, ref, path)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		for _, p := range ix.paths {
+			if p != resource && !strings.HasPrefix(p, resource+"/") {
+				continue
+			}
+			if ix.nodes[p]["$anchor"] == fragment || ix.nodes[p]["$dynamicAnchor"] == fragment {
+				return p, true, nil
+			}
+		}
+		return "", true, fmt.Errorf("%w: dangling $ref %q at %q: no such anchor", ErrInvalidSchema, ref, path)
+	}
+	if _, ok := resolveJSONPointer(ix.root, resource+fragment); !ok {
+		return "", true, fmt.Errorf("%w: dangling $ref %q at %q", ErrInvalidSchema, ref, path)
+	}
+	return resource + fragment, true, nil
+}
+
+// checkInternalRefs verifies that every same-document "$ref" (one starting
+// with "#") in the indexed schema resolves to an existing node. Other
+// references are left to the resolver.
+func checkInternalRefs(ix *schemaIndex) error {
+	for _, path := range ix.paths {
+		ref, ok := ix.nodes[path]["$ref"].(string)
+		if !ok {
+			continue
+		}
+		if _, _, err := ix.resolveLocalRef(path, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasAnchor reports whether any node in schema declares the given $anchor or
+// $dynamicAnchor.
+func hasAnchor(schema map[string]any, anchor string) bool {
+	found := false
+	walkSchema(schema, func(_ string, node map[string]any) bool {
+		if node["$anchor"] == anchor || node["$dynamicAnchor"] == anchor {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// inPlaceKeywords apply their subschemas to the same instance location as the
+// schema containing them, so following them never consumes any input.
+var inPlaceKeywords = []string{"allOf", "anyOf", "dependentSchemas", "else", "if", "not", "oneOf", "then"}
+
+// checkExternalIDs rejects any "$id" in schema that is an absolute http or
+// https URI. Such an $id rebases the relative "$ref"s beneath it onto a
+// remote location, which the blocked loader would otherwise be asked to
+// fetch. Other identifiers, such as urn: URIs, are left to the resolver.
+func checkExternalIDs(schema map[string]any) error {
+	var err error
+	walkSchema(schema, func(path string, node map[string]any) bool {
+		id, ok := node["$id"].(string)
+		if !ok || err != nil {
+			return err == nil
+		}
+		if u, perr := url.Parse(id); perr == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			err = fmt.Errorf("%w: $id %q at %q rebases references onto a remote location", ErrExternalRef, id, path)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// checkRefCycles rejects schemas whose same-document "$ref"s form a cycle that
+// never descends into the instance, such as two definitions referring to each
+// other. Validation against such a schema cannot terminate. Recursion through
+// "properties", "items" and similar keywords is legitimate (a tree schema's
+// base case is an instance with no children) and is not reported.
+func checkRefCycles(ix *schemaIndex) error {
+	nodes, paths := ix.nodes, ix.paths
+	edges := func(path string) []string {
+		node := nodes[path]
+		var out []string
+		if ref, ok := node["$ref"].(string); ok {
+			if target, ok, err := ix.resolveLocalRef(path, ref); ok && err == nil {
+				out = append(out, target)
+			}
+		}
+		for _, kw := range inPlaceKeywords {
+			switch v := node[kw].(type) {
+			case map[string]any:
+				if kw == "dependentSchemas" {
+					for _, k := range sortedKeys(v) {
+						out = append(out, path+"/"+kw+"/"+escapeJSONPointer(k))
+					}
+				} else {
+					out = append(out, path+"/"+kw)
+				}
+			case []any:
+				for i := range v {
+					out = append(out, path+"/"+kw+"/"+strconv.Itoa(i))
+				}
+			}
+		}
+		return out
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(nodes))
+	var visit func(path string) error
+	visit = func(path string) error {
+		switch state[path] {
+		case visiting:
+			return fmt.Errorf("%w: $ref cycle through %q never reaches a base case", ErrInvalidSchema, path)
+		case done:
+			return nil
+		}
+		state[path] = visiting
+		for _, next := range edges(path) {
+			if _, ok := nodes[next]; !ok {
+				continue
+			}
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		state[path] = done
+		return nil
+	}
+	for _, path := range paths {
+		if err := visit(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PruneUnusedDefs returns a copy of schema without the "$defs" (and draft-07
+// "definitions") entries that cannot be reached from the schema body through
+// same-document "$ref"s, following references between definitions
+// transitively. Definition keywords left empty are removed entirely. Refs
+// inside nested schema resources (subschemas with their own "$id") resolve
+// against that resource and do not keep root definitions alive.
+func PruneUnusedDefs(schema any) (map[string]any, error) {
+	root, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	type defKey struct{ keyword, name string }
+	defs := make(map[defKey]map[string]any)
+	for _, kw := range []string{"$defs", "definitions"} {
+		m, _ := root[kw].(map[string]any)
+		for name, v := range m {
+			node, _ := v.(map[string]any)
+			defs[defKey{kw, name}] = node
+		}
+	}
+
+	used := make(map[defKey]bool)
+	var pending []defKey
+	markRef := func(ref string) {
+		if !strings.HasPrefix(ref, "#") {
+			return
+		}
+		fragment, err := url.PathUnescape(ref[1:])
+		if err != nil {
+			return
+		}
+		var key defKey
+		if strings.HasPrefix(fragment, "/") {
+			tokens := strings.SplitN(fragment[1:], "/", 3)
+			if len(tokens) < 2 {
+				return
+			}
+			key = defKey{jsonPointerUnescaper.Replace(tokens[0]), jsonPointerUnescaper.Replace(tokens[1])}
+		} else {
+			for k, node := range defs {
+				if node != nil && hasAnchor(node, fragment) {
+					key = k
+					break
+				}
+			}
+		}
+		if _, ok := defs[key]; ok && !used[key] {
+			used[key] = true
+			pending = append(pending, key)
+		}
+	}
+	collect := func(node map[string]any, skipDefs bool) {
+		walkSchema(node, func(path string, n map[string]any) bool {
+			if path != "" {
+				if _, ok := n["$id"]; ok {
+					return false
+				}
+			}
+			if skipDefs && (strings.HasPrefix(path, "/$defs/") || strings.HasPrefix(path, "/definitions/")) {
+				return false
+			}
+			if ref, ok := n["$ref"].(string); ok {
+				markRef(ref)
+			}
+			return true
+		})
+	}
+
+	collect(root, true)
+	for len(pending) > 0 {
+		key := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if node := defs[key]; node != nil {
+			collect(node, false)
+		}
+	}
+
+	for key := range defs {
+		if used[key] {
+			continue
+		}
+		m := root[key.keyword].(map[string]any)
+		delete(m, key.name)
+		if len(m) == 0 {
+			delete(root, key.keyword)
+		}
+	}
+	return root, nil
+}
+
+// schemaTypeSynonyms maps loose type names seen in non-standard sources to
+// JSON Schema types.
+var schemaTypeSynonyms = map[string]string{
+	"int": "integer", "int32": "integer", "int64": "integer", "long": "integer",
+	"float": "number", "float32": "number", "float64": "number", "double": "number", "decimal": "number",
+	"bool": "boolean",
+	"str":  "string", "text": "string",
+	"dict": "object", "map": "object",
+	"list": "array",
+	"none": "null", "nil": "null",
+}
+
+var jsonSchemaTypes = map[string]bool{
+	"array": true, "boolean": true, "integer": true, "null": true,
+	"number": true, "object": true, "string": true,
+}
+
+// NormalizeSchemaTypes returns a copy of schema in which loose "type" names
+// are replaced by JSON Schema types throughout, e.g. "int" → "integer",
+// "bool" → "boolean", "str" → "string", and "float" → "number". Matching is
+// case-insensitive; unknown names are left for validation to reject.
+func NormalizeSchemaTypes(schema any) (map[string]any, error) {
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	normalize := func(name string) string {
+		lower := strings.ToLower(name)
+		if t, ok := schemaTypeSynonyms[lower]; ok {
+			return t
+		}
+		if jsonSchemaTypes[lower] {
+			return lower
+		}
+		return name
+	}
+	walkSchema(m, func(_ string, node map[string]any) bool {
+		switch t := node["type"].(type) {
+		case string:
+			node["type"] = normalize(t)
+		case []any:
+			for i, v := range t {
+				if name, ok := v.(string); ok {
+					t[i] = normalize(name)
+				}
+			}
+		}
+		return true
+	})
+	return m, nil
+}
+
+// EnforceNoUnevaluated returns a copy of schema with "unevaluatedProperties":
+// false set at the root, replacing any existing value. Unlike
+// "additionalProperties", it also sees properties declared inside "allOf",
+// "anyOf", "oneOf", "$ref" and conditional subschemas, so arguments may carry
+// only properties that some applicable subschema declares. The
+// DefaultValidator enforces the keyword for draft-07 schemas too.
+func EnforceNoUnevaluated(schema any) (map[string]any, error) {
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	m["unevaluatedProperties"] = false
+	return m, nil
+}
+
+// upgradeToDraft202012 returns a copy of schema rewritten from draft-07 (and
+// earlier) forms to their JSON Schema 2020-12 equivalents:
+//
+//   - a draft-07 "$schema" is removed, as 2020-12 is the default;
+//   - boolean "exclusiveMinimum"/"exclusiveMaximum" become numeric bounds
+//     taking the value of "minimum"/"maximum";
+//   - the root "definitions" move to "$defs", and "#/definitions/..."
+//     references follow them; a name defined differently in both returns
+//     ErrInvalidSchema;
+//   - tuple "items" arrays become "prefixItems", with "additionalItems" as
+//     the new "items";
+//   - "dependencies" splits into "dependentRequired" and "dependentSchemas".
+//
+// Any other "$schema" returns ErrUnsupportedSchema.
+func upgradeToDraft202012(schema any) (map[string]any, error) {
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	renameDefs := false
+	if defs, ok := m["definitions"].(map[string]any); ok {
+		merged, _ := m["$defs"].(map[string]any)
+		if merged == nil {
+			merged = make(map[string]any, len(defs))
+		}
+		for _, name := range sortedKeys(defs) {
+			if existing, ok := merged[name]; ok && !reflect.DeepEqual(existing, defs[name]) {
+				return nil, fmt.Errorf("%w: %q is defined differently in \"definitions\" and \"$defs\"", ErrInvalidSchema, name)
+			}
+			merged[name] = defs[name]
+		}
+		m["$defs"] = merged
+		delete(m, "definitions")
+		renameDefs = true
+	}
+	var unsupported error
+	walkSchema(m, func(_ string, node map[string]any) bool {
+		if dialect, ok := node["$schema"].(string); ok {
+			switch {
+			case dialect == SchemaDialect202012 || strings.HasPrefix(dialect, "https://json-schema.org/draft/2020-12/"):
+			case strings.HasPrefix(dialect, "http://json-schema.org/draft-07/"):
+				delete(node, "$schema")
+			default:
+				unsupported = fmt.Errorf("%w: %s (only 2020-12 and draft-07 are supported)", ErrUnsupportedSchema, dialect)
+				return false
+			}
+		}
+		upgradeExclusiveBound(node, "exclusiveMinimum", "minimum")
+		upgradeExclusiveBound(node, "exclusiveMaximum", "maximum")
+		if ref, ok := node["$ref"].(string); ok && renameDefs && strings.HasPrefix(ref, "#/definitions/") {
+			node["$ref"] = "#/$defs/" + strings.TrimPrefix(ref, "#/definitions/")
+		}
+		if tuple, ok := node["items"].([]any); ok {
+			node["prefixItems"] = tuple
+			delete(node, "items")
+			if additional, ok := node["additionalItems"]; ok {
+				node["items"] = additional
+			}
+		}
+		delete(node, "additionalItems")
+		if deps, ok := node["dependencies"].(map[string]any); ok {
+			required := make(map[string]any)
+			schemas := make(map[string]any)
+			for name, dep := range deps {
+				if _, ok := dep.([]any); ok {
+					required[name] = dep
+				} else {
+					schemas[name] = dep
+				}
+			}
+			if len(required) > 0 {
+				node["dependentRequired"] = required
+			}
+			if len(schemas) > 0 {
+				node["dependentSchemas"] = schemas
+			}
+			delete(node, "dependencies")
+		}
+		return true
+	})
+	if unsupported != nil {
+		return nil, unsupported
+	}
+	return m, nil
+}
+
+// upgradeExclusiveBound converts a boolean exclusive keyword, which modifies
+// the inclusive bound, to the numeric 2020-12 form.
+func upgradeExclusiveBound(node map[string]any, exclusive, inclusive string) {
+	flag, ok := node[exclusive].(bool)
+	if !ok {
+		return
+	}
+	delete(node, exclusive)
+	if bound, ok := node[inclusive]; ok && flag {
+		node[exclusive] = bound
+		delete(node, inclusive)
+	}
+}
+
+// SchemaStatistics summarizes the size and complexity of a schema, for
+// catalog analytics.
+type SchemaStatistics struct {
+	// Properties counts property declarations at every level.
+	Properties int
+	// MaxDepth is the deepest subschema nesting: 0 for a schema without
+	// subschemas, 1 for a flat object of scalar properties.
+	MaxDepth int
+	// Required counts the entries of every "required" array.
+	Required int
+	// Enums counts the subschemas declaring "enum".
+	Enums int
+	// UsesRefs reports whether any subschema declares "$ref".
+	UsesRefs bool
+	// UsesCombinators reports whether any subschema declares "allOf",
+	// "anyOf" or "oneOf".
+	UsesCombinators bool
+}
+
+// SchemaStats computes SchemaStatistics for schema. Boolean schemas have
+// zero statistics.
+func SchemaStats(schema any) (SchemaStatistics, error) {
+	var stats SchemaStatistics
+	if _, ok := booleanSchema(schema); ok {
+		return stats, nil
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return stats, err
+	}
+	// walkSchema visits nodes depth first, so the ancestors of the current
+	// node are exactly the stacked paths that prefix it.
+	var stack []string
+	walkSchema(m, func(path string, node map[string]any) bool {
+		for len(stack) > 0 && !strings.HasPrefix(path, stack[len(stack)-1]+"/") {
+			stack = stack[:len(stack)-1]
+		}
+		if path != "" {
+			stack = append(stack, path)
+		}
+		stats.MaxDepth = max(stats.MaxDepth, len(stack))
+
+		stats.Properties += len(schemaProperties(node))
+		stats.Required += len(schemaRequired(node))
+		if _, ok := node["enum"]; ok {
+			stats.Enums++
+		}
+		if _, ok := node["$ref"].(string); ok {
+			stats.UsesRefs = true
+		}
+		for _, kw := range []string{"allOf", "anyOf", "oneOf"} {
+			if _, ok := node[kw].([]any); ok {
+				stats.UsesCombinators = true
+			}
+		}
+		return true
+	})
+	return stats, nil
+}
+
+// valueConstraintKeywords narrow the values a typed property accepts. They
+// are used by SchemaTightness.
+var valueConstraintKeywords = []string{
+	"const", "enum", "exclusiveMaximum", "exclusiveMinimum", "format", "items",
+	"maxItems", "maxLength", "maxProperties", "maximum", "minItems",
+	"minLength", "minProperties", "minimum", "multipleOf", "pattern",
+	"prefixItems", "properties", "uniqueItems",
+}
+
+// SchemaTightness returns a heuristic score between 0 and 1 of how
+// constrained a tool input schema is, for ranking schema quality. It averages
+// two halves:
+//
+//   - the root: one third each for declaring "properties", listing
+//     "required" properties, and closing the object with
+//     "additionalProperties" or "unevaluatedProperties" set to false;
+//   - the top-level properties: each scores one half for declaring a "type"
+//     (or "const"/"enum"/"$ref") and one half for a value constraint such as
+//     "enum", bounds, "pattern", "format" or typed "items" (a false
+//     subschema scores 1); the scores are
+//     averaged, and count as 0 when there are no properties.
+//
+// {} and true score 0, a closed object of fully constrained required
+// properties scores 1, and false (which accepts nothing) scores 1. Nested
+// subschemas are not scored.
+func SchemaTightness(schema any) (float64, error) {
+	if b, ok := booleanSchema(schema); ok {
+		if b {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	props := schemaProperties(m)
+	var root float64
+	if len(props) > 0 {
+		root++
+	}
+	if len(schemaRequired(m)) > 0 {
+		root++
+	}
+	if m["additionalProperties"] == false || m["unevaluatedProperties"] == false {
+		root++
+	}
+
+	var properties float64
+	for _, raw := range props {
+		prop, ok := raw.(map[string]any)
+		if !ok {
+			if raw == false {
+				properties++
+			}
+			continue
+		}
+		for _, kw := range []string{"type", "const", "enum", "$ref"} {
+			if _, ok := prop[kw]; ok {
+				properties += 0.5
+				break
+			}
+		}
+		for _, kw := range valueConstraintKeywords {
+			if _, ok := prop[kw]; ok {
+				properties += 0.5
+				break
+			}
+		}
+	}
+	if len(props) > 0 {
+		properties /= float64(len(props))
+	}
+	return (root/3 + properties) / 2, nil
+}
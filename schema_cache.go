@@ -0,0 +1,90 @@
+package toolmodel
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+)
+
+// schemaCache memoizes compiled schemas by a hash of their JSON encoding,
+// evicting the least recently used entry beyond size entries. It is safe for
+// concurrent use.
+type schemaCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *schemaCacheEntry, most recently used first
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type schemaCacheEntry struct {
+	key      [sha256.Size]byte
+	compiled *compiledSchema
+}
+
+func newSchemaCache(size int) *schemaCache {
+	return &schemaCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+func (c *schemaCache) get(key [sha256.Size]byte) (*compiledSchema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*schemaCacheEntry).compiled, true
+}
+
+func (c *schemaCache) put(key [sha256.Size]byte, compiled *compiledSchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&schemaCacheEntry{key: key, compiled: compiled})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*schemaCacheEntry).key)
+	}
+}
+
+func (c *schemaCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// schemaCacheKey hashes the JSON encoding of schema together with the
+// validator options that change how it resolves. ok is false when schema
+// cannot be encoded; such schemas are compiled uncached so the error is reported.
+func (v *DefaultValidator) schemaCacheKey(schema any) (key [sha256.Size]byte, ok bool) {
+	var data []byte
+	switch s := schema.(type) {
+	case json.RawMessage:
+		data = s
+	case []byte:
+		data = s
+	default:
+		data, _ = json.Marshal(s)
+	}
+	if len(data) == 0 {
+		return key, false
+	}
+	h := sha256.New()
+	if v.rejectExternalIDs {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+	h.Write(data)
+	copy(key[:], h.Sum(nil))
+	return key, true
+}
@@ -0,0 +1,677 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema(
+		map[string]any{"query": "go", "limit": 10, "exact": true, "tags": []any{"a"}},
+		map[string]any{"query": "rust", "limit": 2.5, "filter": map[string]any{"lang": "en"}},
+	)
+	if err != nil {
+		t.Fatalf("InferSchema() error = %v", err)
+	}
+
+	want := map[string]any{
+		"$schema": SchemaDialect202012,
+		"type":    "object",
+		"properties": map[string]any{
+			"exact": map[string]any{"type": "boolean"},
+			"filter": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"lang": map[string]any{"type": "string"},
+				},
+				"required": []any{"lang"},
+			},
+			"limit": map[string]any{"type": "number"},
+			"query": map[string]any{"type": "string"},
+			"tags": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+		},
+		"required": []any{"limit", "query"},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("InferSchema() = %#v, want %#v", schema, want)
+	}
+
+	v := NewDefaultValidator()
+	if err := v.Validate(schema, map[string]any{"query": "zig", "limit": 3}); err != nil {
+		t.Errorf("inferred schema rejected a conforming instance: %v", err)
+	}
+}
+
+func TestInferSchema_MixedTypes(t *testing.T) {
+	schema, err := InferSchema(
+		map[string]any{"id": 1},
+		map[string]any{"id": "abc"},
+		map[string]any{"id": nil},
+	)
+	if err != nil {
+		t.Fatalf("InferSchema() error = %v", err)
+	}
+	id := schema["properties"].(map[string]any)["id"].(map[string]any)
+	want := []any{"integer", "null", "string"}
+	if !reflect.DeepEqual(id["type"], want) {
+		t.Errorf("id type = %#v, want %#v", id["type"], want)
+	}
+}
+
+func TestInferSchema_NoExamples(t *testing.T) {
+	if _, err := InferSchema(); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("InferSchema() error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestSchemaMergePatch_RoundTrip(t *testing.T) {
+	old := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string", "minLength": 1},
+			"limit": map[string]any{"type": "integer", "default": 10},
+			"debug": map[string]any{"type": "boolean"},
+		},
+		"required": []any{"query"},
+	}
+	new := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string", "minLength": 1},
+			"limit": {"type": "integer", "default": 20, "maximum": 100},
+			"cursor": {"type": "string"}
+		},
+		"required": ["query", "limit"]
+	}`)
+
+	patch, err := SchemaMergePatch(old, new)
+	if err != nil {
+		t.Fatalf("SchemaMergePatch() error = %v", err)
+	}
+
+	var gotPatch map[string]any
+	if err := json.Unmarshal(patch, &gotPatch); err != nil {
+		t.Fatalf("patch is not a JSON object: %v", err)
+	}
+	wantPatch := map[string]any{
+		"properties": map[string]any{
+			"limit":  map[string]any{"default": float64(20), "maximum": float64(100)},
+			"cursor": map[string]any{"type": "string"},
+			"debug":  nil,
+		},
+		"required": []any{"query", "limit"},
+	}
+	if !reflect.DeepEqual(gotPatch, wantPatch) {
+		t.Errorf("SchemaMergePatch() = %s, want %v", patch, wantPatch)
+	}
+
+	applied, err := ApplySchemaMergePatch(old, patch)
+	if err != nil {
+		t.Fatalf("ApplySchemaMergePatch() error = %v", err)
+	}
+	var want map[string]any
+	if err := json.Unmarshal(new, &want); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("ApplySchemaMergePatch() = %v, want %v", applied, want)
+	}
+	if _, ok := old["properties"].(map[string]any)["debug"]; !ok {
+		t.Error("ApplySchemaMergePatch() mutated the base schema")
+	}
+}
+
+func TestSchemaMergePatch_NoChange(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	patch, err := SchemaMergePatch(schema, []byte(`{"type":"object"}`))
+	if err != nil {
+		t.Fatalf("SchemaMergePatch() error = %v", err)
+	}
+	if string(patch) != "{}" {
+		t.Errorf("SchemaMergePatch() = %s, want {}", patch)
+	}
+}
+
+func TestApplySchemaMergePatch_Invalid(t *testing.T) {
+	base := map[string]any{"type": "object"}
+	if _, err := ApplySchemaMergePatch(base, []byte("not json")); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ApplySchemaMergePatch() error = %v, want ErrInvalidSchema", err)
+	}
+	if _, err := ApplySchemaMergePatch(base, []byte(`"replaced"`)); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ApplySchemaMergePatch() error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestNoParametersSchema(t *testing.T) {
+	v := NewDefaultValidator()
+	tool := Tool{}
+	tool.Name = "get_time"
+	tool.InputSchema = NoParametersSchema()
+
+	if err := v.ValidateInput(&tool, map[string]any{}); err != nil {
+		t.Errorf("ValidateInput() empty args error = %v, want nil", err)
+	}
+	if err := v.ValidateInput(&tool, map[string]any{"extra": 1}); err == nil {
+		t.Error("ValidateInput() expected error for extra property")
+	}
+	if issues, err := LintSchema(NoParametersSchema()); err != nil || len(issues) != 0 {
+		t.Errorf("LintSchema() = %v, %v, want no issues", issues, err)
+	}
+
+	// Each call returns an independent map.
+	NoParametersSchema()["additionalProperties"] = true
+	if NoParametersSchema()["additionalProperties"] != false {
+		t.Error("NoParametersSchema() returned a shared map")
+	}
+}
+
+func TestNormalizeJSONNumbers(t *testing.T) {
+	in := map[string]any{
+		"whole":    10.0,
+		"frac":     2.5,
+		"int":      7,
+		"list":     []any{1.0, 1.5, int32(3)},
+		"nested":   map[string]any{"n": json.Number("42")},
+		"huge":     1e300,
+		"string":   "10",
+		"negative": -3.0,
+		"uint64":   json.Number("18446744073709551615"),
+		"wholeNum": json.Number("1.0e3"),
+		"infinite": json.Number("1e400"),
+	}
+	got := normalizeJSONNumbers(in)
+	want := map[string]any{
+		"whole":    int64(10),
+		"frac":     2.5,
+		"int":      int64(7),
+		"list":     []any{int64(1), 1.5, int64(3)},
+		"nested":   map[string]any{"n": int64(42)},
+		"huge":     1e300,
+		"string":   "10",
+		"negative": int64(-3),
+		"uint64":   json.Number("18446744073709551615"),
+		"wholeNum": int64(1000),
+		"infinite": json.Number("1e400"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeJSONNumbers() = %#v, want %#v", got, want)
+	}
+	if in["whole"] != 10.0 {
+		t.Error("normalizeJSONNumbers() mutated its input")
+	}
+}
+
+func TestCanonicalizeSchema_MixedNumbers(t *testing.T) {
+	intDefault := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"limit": map[string]any{"type": "integer", "default": 10}},
+	}
+	floatDefault := json.RawMessage(`{"properties":{"limit":{"default":10.0,"type":"integer"}},"type":"object"}`)
+
+	a, err := CanonicalizeSchema(intDefault)
+	if err != nil {
+		t.Fatalf("CanonicalizeSchema() error = %v", err)
+	}
+	b, err := CanonicalizeSchema(floatDefault)
+	if err != nil {
+		t.Fatalf("CanonicalizeSchema() error = %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("CanonicalizeSchema() = %v and %v, want equal", a, b)
+	}
+
+	ja, _ := CanonicalJSON(intDefault)
+	jb, _ := CanonicalJSON(floatDefault)
+	if string(ja) != string(jb) {
+		t.Errorf("CanonicalJSON() = %s and %s, want equal", ja, jb)
+	}
+}
+
+func TestValidateSchemaUpdate(t *testing.T) {
+	old := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"q":     map[string]any{"type": "string"},
+			"limit": map[string]any{"type": "integer"},
+		},
+		"required": []any{"q"},
+	}
+
+	t.Run("compatible update", func(t *testing.T) {
+		updated := map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"q":     map[string]any{"type": "string", "minLength": 1},
+				"limit": map[string]any{"type": "number"},
+				"page":  map[string]any{"type": "integer"},
+			},
+			"required": []any{"q"},
+		}
+		if err := ValidateSchemaUpdate(old, updated); err != nil {
+			t.Errorf("ValidateSchemaUpdate() error = %v", err)
+		}
+		if changes, err := SchemaUpdateChanges(old, updated); err != nil || changes != nil {
+			t.Errorf("SchemaUpdateChanges() = %v, %v, want no changes", changes, err)
+		}
+	})
+
+	t.Run("breaking updates", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			updated map[string]any
+			want    []string
+		}{
+			{
+				name: "required field added",
+				updated: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"q":     map[string]any{"type": "string"},
+						"limit": map[string]any{"type": "integer"},
+					},
+					"required": []any{"q", "limit"},
+				},
+				want: []string{`property "limit" is newly required`},
+			},
+			{
+				name: "type narrowed",
+				updated: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"q":     map[string]any{"type": "string"},
+						"limit": map[string]any{"type": "string"},
+					},
+					"required": []any{"q"},
+				},
+				want: []string{`property "limit" no longer accepts type "integer"`},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := ValidateSchemaUpdate(old, tt.updated); err != nil {
+					t.Errorf("ValidateSchemaUpdate() error = %v, want nil for a well-formed update", err)
+				}
+				changes, err := SchemaUpdateChanges(old, tt.updated)
+				if err != nil {
+					t.Fatalf("SchemaUpdateChanges() error = %v", err)
+				}
+				if !reflect.DeepEqual(changes, tt.want) {
+					t.Errorf("SchemaUpdateChanges() = %v, want %v", changes, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("malformed update", func(t *testing.T) {
+		updated := map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"q": map[string]any{"$ref": "#/$defs/missing"},
+			},
+			"required": []any{"q", "page"},
+		}
+		err := ValidateSchemaUpdate(old, updated)
+		if !errors.Is(err, ErrInvalidSchema) {
+			t.Fatalf("ValidateSchemaUpdate() error = %v, want ErrInvalidSchema", err)
+		}
+		var breaking *BreakingChangesError
+		if !errors.As(err, &breaking) {
+			t.Errorf("ValidateSchemaUpdate() error = %v, want *BreakingChangesError too", err)
+		}
+		for _, want := range []string{`property "limit" removed`, `property "page" is newly required`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("ValidateSchemaUpdate() error = %v, want it to mention %s", err, want)
+			}
+		}
+	})
+
+	t.Run("unparseable update", func(t *testing.T) {
+		if err := ValidateSchemaUpdate(old, json.RawMessage(`{"type":`)); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("ValidateSchemaUpdate() error = %v, want ErrInvalidSchema", err)
+		}
+	})
+}
+
+func TestSchemaIndex_ResolveLocalRef(t *testing.T) {
+	ix := newSchemaIndex(map[string]any{
+		"$defs": map[string]any{
+			"name": map[string]any{"type": "string", "$anchor": "name"},
+			"nested": map[string]any{
+				"$id":   "nested",
+				"$defs": map[string]any{"inner": map[string]any{"type": "integer"}},
+				"$ref":  "#/$defs/inner",
+			},
+		},
+		"properties": map[string]any{
+			"a": map[string]any{"$ref": "#/$defs/name"},
+			"b": map[string]any{"$ref": "#name"},
+			"c": map[string]any{"$ref": "#/$defs/missing"},
+			"d": map[string]any{"$ref": "#nope"},
+			"e": map[string]any{"$ref": "https://example.com/s"},
+		},
+	})
+	tests := []struct {
+		path    string
+		ref     string
+		want    string
+		wantOK  bool
+		wantErr bool
+	}{
+		{path: "/properties/a", ref: "#/$defs/name", want: "/$defs/name", wantOK: true},
+		{path: "/properties/b", ref: "#name", want: "/$defs/name", wantOK: true},
+		{path: "/$defs/nested", ref: "#/$defs/inner", want: "/$defs/nested/$defs/inner", wantOK: true},
+		{path: "/properties/c", ref: "#/$defs/missing", wantOK: true, wantErr: true},
+		{path: "/properties/d", ref: "#nope", wantOK: true, wantErr: true},
+		{path: "/properties/e", ref: "https://example.com/s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, ok, err := ix.resolveLocalRef(tt.path, tt.ref)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("resolveLocalRef() ok = %v, error = %v, want ok %v, error %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("resolveLocalRef() error = %v, want ErrInvalidSchema", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveLocalRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStableJSON(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"zeta": {"type": "integer", "default": 10.0},
+			"alpha": {"type": "string"}
+		}
+	}`)
+
+	sorted, err := StableJSON(schema, false)
+	if err != nil {
+		t.Fatalf("StableJSON(false) error = %v", err)
+	}
+	wantSorted := `{"properties":{"alpha":{"type":"string"},"zeta":{"default":10,"type":"integer"}},"type":"object"}`
+	if string(sorted) != wantSorted {
+		t.Errorf("StableJSON(false) = %s, want %s", sorted, wantSorted)
+	}
+
+	preserved, err := StableJSON(schema, true)
+	if err != nil {
+		t.Fatalf("StableJSON(true) error = %v", err)
+	}
+	wantPreserved := `{"type":"object","properties":{"zeta":{"type":"integer","default":10.0},"alpha":{"type":"string"}}}`
+	if string(preserved) != wantPreserved {
+		t.Errorf("StableJSON(true) = %s, want %s", preserved, wantPreserved)
+	}
+
+	if _, err := StableJSON(json.RawMessage(`[1]`), true); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("StableJSON(array) error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestPruneUnusedDefs(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"user": map[string]any{"$ref": "#/$defs/user"},
+			"tag":  map[string]any{"$ref": "#tag"},
+		},
+		"$defs": map[string]any{
+			"user":    map[string]any{"type": "object", "properties": map[string]any{"addr": map[string]any{"$ref": "#/$defs/address"}}},
+			"address": map[string]any{"type": "string"},
+			"tag":     map[string]any{"$anchor": "tag", "type": "string"},
+			"unused":  map[string]any{"$ref": "#/$defs/orphan"},
+			"orphan":  map[string]any{"type": "integer"},
+		},
+		"definitions": map[string]any{
+			"legacy": map[string]any{"type": "boolean"},
+		},
+	}
+
+	got, err := PruneUnusedDefs(schema)
+	if err != nil {
+		t.Fatalf("PruneUnusedDefs() error = %v", err)
+	}
+	defs, _ := got["$defs"].(map[string]any)
+	var kept []string
+	for name := range defs {
+		kept = append(kept, name)
+	}
+	sort.Strings(kept)
+	if want := []string{"address", "tag", "user"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("PruneUnusedDefs() kept %v, want %v", kept, want)
+	}
+	if _, ok := got["definitions"]; ok {
+		t.Error("PruneUnusedDefs() kept empty definitions keyword")
+	}
+	if _, ok := schema["$defs"].(map[string]any)["unused"]; !ok {
+		t.Error("PruneUnusedDefs() modified its input")
+	}
+	if err := ValidateSchema(got); err != nil {
+		t.Errorf("pruned schema is invalid: %v", err)
+	}
+}
+
+func TestNormalizeSchemaTypes(t *testing.T) {
+	schema := map[string]any{
+		"type": "dict",
+		"properties": map[string]any{
+			"count":   map[string]any{"type": "int"},
+			"enabled": map[string]any{"type": "Bool"},
+			"tags":    map[string]any{"type": "list", "items": map[string]any{"type": "str"}},
+			"ratio":   map[string]any{"type": []any{"float", "none"}},
+		},
+	}
+
+	got, err := NormalizeSchemaTypes(schema)
+	if err != nil {
+		t.Fatalf("NormalizeSchemaTypes() error = %v", err)
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count":   map[string]any{"type": "integer"},
+			"enabled": map[string]any{"type": "boolean"},
+			"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"ratio":   map[string]any{"type": []any{"number", "null"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeSchemaTypes() = %v, want %v", got, want)
+	}
+	if schema["type"] != "dict" {
+		t.Error("NormalizeSchemaTypes() modified its input")
+	}
+
+	v := NewDefaultValidator()
+	if err := v.Validate(schema, map[string]any{"count": 3}); err == nil {
+		t.Error("Validate() with loose types error = nil, want failure")
+	}
+	if err := v.Validate(got, map[string]any{"count": 3}); err != nil {
+		t.Errorf("Validate() after normalization error = %v", err)
+	}
+	if err := v.Validate(got, map[string]any{"count": "3"}); err == nil {
+		t.Error("Validate() after normalization accepted a string for an integer")
+	}
+}
+
+func TestSchemaStats(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"query", "filter"},
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string"},
+			"sort":  map[string]any{"enum": []any{"asc", "desc"}},
+			"filter": map[string]any{
+				"type":     "object",
+				"required": []any{"field"},
+				"properties": map[string]any{
+					"field": map[string]any{"type": "string"},
+					"value": map[string]any{
+						"anyOf": []any{
+							map[string]any{"type": "string"},
+							map[string]any{"$ref": "#/$defs/range"},
+						},
+					},
+				},
+			},
+		},
+		"$defs": map[string]any{
+			"range": map[string]any{"type": "array", "items": map[string]any{"type": "number"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		schema any
+		want   SchemaStatistics
+	}{
+		{
+			name:   "nested",
+			schema: schema,
+			want: SchemaStatistics{
+				Properties: 5, MaxDepth: 3, Required: 3, Enums: 1,
+				UsesRefs: true, UsesCombinators: true,
+			},
+		},
+		{
+			name:   "flat",
+			schema: map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{"type": "string"}}},
+			want:   SchemaStatistics{Properties: 1, MaxDepth: 1},
+		},
+		{name: "boolean", schema: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SchemaStats(tt.schema)
+			if err != nil {
+				t.Fatalf("SchemaStats() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SchemaStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := SchemaStats("not a schema"); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("SchemaStats(invalid) error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestEnforceNoUnevaluated(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"allOf": []any{
+			map[string]any{"properties": map[string]any{"name": map[string]any{"type": "string"}}},
+			map[string]any{"$ref": "#/$defs/paging"},
+		},
+		"$defs": map[string]any{
+			"paging": map[string]any{"properties": map[string]any{"limit": map[string]any{"type": "integer"}}},
+		},
+	}
+	valid := map[string]any{"name": "ada", "limit": 10}
+	extra := map[string]any{"name": "ada", "stray": true}
+
+	v := NewDefaultValidator()
+	if err := v.Validate(schema, extra); err != nil {
+		t.Fatalf("Validate() before enforcement error = %v", err)
+	}
+
+	strict, err := EnforceNoUnevaluated(schema)
+	if err != nil {
+		t.Fatalf("EnforceNoUnevaluated() error = %v", err)
+	}
+	if err := v.Validate(strict, valid); err != nil {
+		t.Errorf("Validate() of declared properties error = %v", err)
+	}
+	err = v.Validate(strict, extra)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Keyword != "unevaluatedProperties" {
+		t.Errorf("Validate() of a stray property error = %v, want unevaluatedProperties failure", err)
+	}
+	if _, ok := schema["unevaluatedProperties"]; ok {
+		t.Error("EnforceNoUnevaluated() modified its input")
+	}
+
+	draft07, err := EnforceNoUnevaluated(json.RawMessage(`{"$schema":"http://json-schema.org/draft-07/schema#","properties":{"name":{}}}`))
+	if err != nil {
+		t.Fatalf("EnforceNoUnevaluated(draft-07) error = %v", err)
+	}
+	if err := v.Validate(draft07, extra); err == nil {
+		t.Error("Validate() of draft-07 schema accepted a stray property")
+	}
+}
+
+func TestSchemaTightness(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		want   float64
+	}{
+		{"empty", map[string]any{}, 0},
+		{"true", true, 0},
+		{"bare object", map[string]any{"type": "object"}, 0},
+		{
+			name: "typed properties",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"q":     map[string]any{"type": "string"},
+					"limit": map[string]any{"type": "integer"},
+				},
+			},
+			want: (1.0/3 + 0.5) / 2,
+		},
+		{
+			name: "closed and constrained",
+			schema: map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []any{"q", "mode"},
+				"properties": map[string]any{
+					"q":     map[string]any{"type": "string", "minLength": 1},
+					"mode":  map[string]any{"enum": []any{"fast", "exact"}},
+					"limit": map[string]any{"type": "integer", "minimum": 1, "maximum": 100},
+				},
+			},
+			want: 1,
+		},
+		{"false", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SchemaTightness(tt.schema)
+			if err != nil {
+				t.Fatalf("SchemaTightness() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SchemaTightness() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	open, _ := SchemaTightness(map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{}}})
+	closed, _ := SchemaTightness(map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           map[string]any{"q": map[string]any{"type": "string", "maxLength": 64}},
+	})
+	if !(closed > open) {
+		t.Errorf("SchemaTightness() constrained = %v, open = %v, want constrained higher", closed, open)
+	}
+
+	if _, err := SchemaTightness("bogus"); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("SchemaTightness(invalid) error = %v, want ErrInvalidSchema", err)
+	}
+}
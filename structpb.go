@@ -0,0 +1,20 @@
+//go:build structpb
+
+package toolmodel
+
+import "google.golang.org/protobuf/types/known/structpb"
+
+// ValidateInputStruct validates arguments delivered as a protobuf Struct, as
+// produced by gRPC layers, against the tool's InputSchema. Struct numbers are
+// always float64; whole values are converted to integers first so they
+// satisfy "type": "integer". A nil Struct is treated as an empty object.
+//
+// This file is only built with the "structpb" build tag, so builds without
+// the tag do not compile the protobuf dependency.
+func (v *DefaultValidator) ValidateInputStruct(tool *Tool, s *structpb.Struct) error {
+	args := map[string]any{}
+	if s != nil {
+		args = s.AsMap()
+	}
+	return v.ValidateInput(tool, normalizeJSONNumbers(args))
+}
@@ -0,0 +1,39 @@
+//go:build structpb
+
+package toolmodel
+
+import (
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestDefaultValidator_ValidateInputStruct(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "page", InputSchema: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"limit": map[string]any{"type": "integer"}},
+		"required":   []any{"limit"},
+	}}}
+	v := NewDefaultValidator()
+
+	valid, err := structpb.NewStruct(map[string]any{"limit": 10})
+	if err != nil {
+		t.Fatalf("NewStruct() error = %v", err)
+	}
+	if err := v.ValidateInputStruct(tool, valid); err != nil {
+		t.Errorf("ValidateInputStruct(integer) error = %v", err)
+	}
+
+	fractional, err := structpb.NewStruct(map[string]any{"limit": 2.5})
+	if err != nil {
+		t.Fatalf("NewStruct() error = %v", err)
+	}
+	if err := v.ValidateInputStruct(tool, fractional); err == nil {
+		t.Error("ValidateInputStruct(fractional) error = nil, want failure")
+	}
+
+	if err := v.ValidateInputStruct(tool, nil); err == nil {
+		t.Error("ValidateInputStruct(nil) error = nil, want missing required failure")
+	}
+}
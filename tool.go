@@ -1,11 +1,20 @@
 package toolmodel
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -42,11 +51,242 @@ type Tool struct {
 	Version string `json:"version,omitempty"`
 	// Tags is an optional set of search keywords for discovery layers (e.g. toolindex).
 	Tags []string `json:"tags,omitempty"`
+	// CostHint is an optional relative cost for budget-aware planning.
+	// Zero means unspecified.
+	CostHint int `json:"costHint,omitempty"`
+	// RateLimitPerMinute is an optional call budget per minute for schedulers.
+	// Zero means unspecified.
+	RateLimitPerMinute int `json:"rateLimitPerMinute,omitempty"`
+	// Hidden excludes the tool from discovery listings while keeping it
+	// callable by ID.
+	Hidden bool `json:"hidden,omitempty"`
+	// Category is an optional human-facing grouping (e.g. "Productivity"),
+	// independent of Namespace.
+	Category string `json:"category,omitempty"`
+	// Backends optionally records where the tool is executed. A tool may be
+	// served by several backends (e.g. an MCP server and a local fallback).
+	Backends []ToolBackend `json:"backends,omitempty"`
+	// Localizations optionally holds translated titles and descriptions,
+	// keyed by BCP-47 language tag (e.g. "fr", "pt-BR"). See Localized.
+	Localizations map[string]ToolL10n `json:"localizations,omitempty"`
+}
+
+// ToolL10n is a translation of a tool's human-facing text. Empty fields fall
+// back to the tool's own values.
+type ToolL10n struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Localized returns a copy of t whose Title and Description are taken from
+// Localizations[lang]. The tag is matched case-insensitively, and a regional
+// tag such as "fr-CA" falls back to its base language "fr". When no
+// translation matches, or a translated field is empty, the base value is
+// kept. The copy shares schemas, slices and maps with t.
+func (t *Tool) Localized(lang string) *Tool {
+	c := *t
+	l10n, ok := t.localization(lang)
+	if !ok {
+		return &c
+	}
+	if l10n.Title != "" {
+		c.Title = l10n.Title
+	}
+	if l10n.Description != "" {
+		c.Description = l10n.Description
+	}
+	return &c
+}
+
+// Clone returns a deep copy of t. Schemas stored as map[string]any,
+// json.RawMessage or []byte are copied, as are the embedded mcp.Tool's
+// metadata, annotations and icons, and the Tags, Backends and Localizations,
+// so mutating the copy never affects t. A *jsonschema.Schema is copied with
+// its CloneSchemas method, which duplicates subschemas; other schema values
+// are shared. Clone returns nil for a nil receiver.
+func (t *Tool) Clone() *Tool {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Meta = cloneJSONValue(map[string]any(t.Meta)).(map[string]any)
+	if t.Annotations != nil {
+		a := *t.Annotations
+		a.DestructiveHint = cloneBoolPtr(a.DestructiveHint)
+		a.OpenWorldHint = cloneBoolPtr(a.OpenWorldHint)
+		c.Annotations = &a
+	}
+	c.InputSchema = cloneSchema(t.InputSchema)
+	c.OutputSchema = cloneSchema(t.OutputSchema)
+	if t.Icons != nil {
+		c.Icons = make([]mcp.Icon, len(t.Icons))
+		for i, icon := range t.Icons {
+			icon.Sizes = slices.Clone(icon.Sizes)
+			c.Icons[i] = icon
+		}
+	}
+	c.Tags = slices.Clone(t.Tags)
+	if t.Backends != nil {
+		c.Backends = make([]ToolBackend, len(t.Backends))
+		for i, b := range t.Backends {
+			c.Backends[i] = b.clone()
+		}
+	}
+	c.Localizations = maps.Clone(t.Localizations)
+	return &c
+}
+
+// clone returns a deep copy of b.
+func (b ToolBackend) clone() ToolBackend {
+	if b.MCP != nil {
+		m := *b.MCP
+		m.AuthScopes = slices.Clone(m.AuthScopes)
+		b.MCP = &m
+	}
+	if b.Provider != nil {
+		p := *b.Provider
+		p.AuthScopes = slices.Clone(p.AuthScopes)
+		b.Provider = &p
+	}
+	if b.Local != nil {
+		l := *b.Local
+		b.Local = &l
+	}
+	return b
+}
+
+func cloneBoolPtr(p *bool) *bool {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// cloneSchema deep-copies the schema representations a Tool may hold.
+func cloneSchema(schema any) any {
+	switch s := schema.(type) {
+	case map[string]any:
+		return cloneJSONValue(s)
+	case json.RawMessage:
+		return json.RawMessage(bytes.Clone(s))
+	case []byte:
+		return bytes.Clone(s)
+	case *jsonschema.Schema:
+		return s.CloneSchemas()
+	default:
+		return schema
+	}
+}
+
+// cloneJSONValue deep-copies decoded JSON: nested maps and slices are
+// duplicated, scalars are returned as is. A nil map or slice stays nil.
+func cloneJSONValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		if x == nil {
+			return x
+		}
+		out := make(map[string]any, len(x))
+		for k, e := range x {
+			out[k] = cloneJSONValue(e)
+		}
+		return out
+	case []any:
+		if x == nil {
+			return x
+		}
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = cloneJSONValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// localization finds the translation for lang, trying the full tag and then
+// each shorter prefix ("zh-Hant-TW", "zh-Hant", "zh").
+func (t *Tool) localization(lang string) (ToolL10n, bool) {
+	for tag := lang; tag != ""; {
+		if l10n, ok := t.Localizations[tag]; ok {
+			return l10n, true
+		}
+		for key, l10n := range t.Localizations {
+			if strings.EqualFold(key, tag) {
+				return l10n, true
+			}
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return ToolL10n{}, false
+}
+
+// DisplayName returns the label to show for the tool: Title when set, then
+// the annotations' title, then Name.
+func (t *Tool) DisplayName() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	if t.Annotations != nil && t.Annotations.Title != "" {
+		return t.Annotations.Title
+	}
+	return t.Name
+}
+
+// SafeForAutoInvoke reports whether an agent may run the tool without asking
+// the user: the tool must be annotated both read-only and idempotent. Per the
+// MCP spec both hints default to false, so a tool without annotations is not
+// safe. DestructiveHint is meaningless for a read-only tool, but one set
+// explicitly to true contradicts the read-only hint, so such a tool is not
+// safe either.
+func (t *Tool) SafeForAutoInvoke() bool {
+	a := t.Annotations
+	if a == nil || !a.ReadOnlyHint || !a.IdempotentHint {
+		return false
+	}
+	return a.DestructiveHint == nil || !*a.DestructiveHint
+}
+
+// IconSources returns the Source of every icon on the tool, in declaration
+// order, skipping empty sources. Icons may repeat a source across sizes or
+// themes; duplicates are kept.
+func (t *Tool) IconSources() []string {
+	var sources []string
+	for _, icon := range t.Icons {
+		if icon.Source != "" {
+			sources = append(sources, icon.Source)
+		}
+	}
+	return sources
+}
+
+// HasBackendKind reports whether any of the tool's Backends is of kind.
+func (t *Tool) HasBackendKind(kind BackendKind) bool {
+	for _, b := range t.Backends {
+		if b.Kind == kind {
+			return true
+		}
+	}
+	return false
 }
 
 // ToolIcon is an alias for mcp.Icon from the official SDK.
 type ToolIcon = mcp.Icon
 
+// MergeTags returns the union of a and b, normalized as by NormalizeTags and
+// sorted, so the result does not depend on argument or input order.
+func MergeTags(a, b []string) []string {
+	merged := append(NormalizeTags(a), NormalizeTags(b)...)
+	sort.Strings(merged)
+	return NormalizeTags(merged)
+}
+
 // NormalizeTags normalizes a list of tags for indexing/search.
 // Rules:
 // - lowercase
@@ -107,6 +347,29 @@ func NormalizeTags(tags []string) []string {
 	return out
 }
 
+// descriptionEllipsis marks a truncated description.
+const descriptionEllipsis = "…"
+
+// TruncateDescription shortens s to at most max runes, cutting only on rune
+// boundaries. When s is truncated, the last rune is replaced by an ellipsis so
+// the result still fits within max. A non-positive max yields "".
+func TruncateDescription(s string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	n := 0
+	for i := range s {
+		if n == max-1 {
+			return s[:i] + descriptionEllipsis
+		}
+		n++
+	}
+	return s
+}
+
 // BackendKind defines the type of backend backing a tool.
 type BackendKind string
 
@@ -129,12 +392,18 @@ type ToolBackend struct {
 type MCPBackend struct {
 	// ServerName identifies the MCP server (e.g. in a registry or config).
 	ServerName string `json:"serverName,omitempty"`
+	// AuthScopes lists the authorization scopes the server requires for
+	// this tool, if any.
+	AuthScopes []string `json:"authScopes,omitempty"`
 }
 
 // ProviderBackend defines metadata for an external/manual tool provider.
 type ProviderBackend struct {
 	ProviderID string `json:"providerId"`
 	ToolID     string `json:"toolId"`
+	// AuthScopes lists the authorization scopes the provider requires for
+	// this tool, if any.
+	AuthScopes []string `json:"authScopes,omitempty"`
 }
 
 // LocalBackend defines metadata for a locally executed tool.
@@ -152,6 +421,45 @@ func (t *Tool) ToolID() string {
 	return t.Namespace + ":" + t.Name
 }
 
+// SortKey returns a key for ordering tools in catalog listings. Keys compare
+// by namespace, then name, then version. Versions that parse as semantic
+// versions ("1.2.3", "v1.2.3-rc.1") are zero-padded so they sort numerically,
+// with pre-releases before the matching release; other versions sort
+// lexically.
+func (t *Tool) SortKey() string {
+	return t.Namespace + "\x00" + t.Name + "\x00" + versionSortKey(t.Version)
+}
+
+// versionSortKey returns a lexically comparable form of a version string.
+func versionSortKey(version string) string {
+	core, pre, _ := strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	core, _, _ = strings.Cut(core, "+")
+	pre, _, _ = strings.Cut(pre, "+")
+	parts := strings.Split(core, ".")
+	if version == "" || len(parts) > 3 {
+		return version
+	}
+	var b strings.Builder
+	for i := 0; i < 3; i++ {
+		n := uint64(0)
+		if i < len(parts) {
+			v, err := strconv.ParseUint(parts[i], 10, 64)
+			if err != nil {
+				return version
+			}
+			n = v
+		}
+		fmt.Fprintf(&b, "%020d.", n)
+	}
+	// Pre-releases sort before the release they precede.
+	if pre != "" {
+		b.WriteString("\x01" + pre)
+	} else {
+		b.WriteString("\x02")
+	}
+	return b.String()
+}
+
 // ParseToolID parses a tool ID string into namespace and name components.
 // The format is "namespace:name" or just "name" (empty namespace).
 // Returns an error if the ID is empty or contains multiple colons.
@@ -184,9 +492,170 @@ func ParseToolID(id string) (namespace, name string, err error) {
 	return namespace, name, nil
 }
 
+// ValidateID checks that ToolID round-trips through ParseToolID back to the
+// tool's own Namespace and Name. It catches edge cases such as a colon in the
+// namespace or name, which would make the ID ambiguous. Dotted namespaces and
+// names (e.g. "a.b:c.d") round-trip cleanly. Failures wrap ErrInvalidToolID.
+func (t *Tool) ValidateID() error {
+	id := t.ToolID()
+	namespace, name, err := ParseToolID(id)
+	if err != nil {
+		return fmt.Errorf("%w: %q does not parse", ErrInvalidToolID, id)
+	}
+	if namespace != t.Namespace || name != t.Name {
+		return fmt.Errorf("%w: %q parses as namespace %q and name %q, want %q and %q",
+			ErrInvalidToolID, id, namespace, name, t.Namespace, t.Name)
+	}
+	return nil
+}
+
+// CanonicalizeToolID returns the canonical "namespace:name" (or "name") form of
+// id. Parsing is lenient: whitespace around the ID and around each component is
+// ignored. Namespaces and names are compared case-insensitively across
+// systems, so both are lowercased. Malformed IDs, including components with
+// characters not allowed in tool names, return ErrInvalidToolID.
+func CanonicalizeToolID(id string) (string, error) {
+	namespace, name, err := parseToolIDLenient(id)
+	if err != nil {
+		return "", err
+	}
+	if namespace == "" {
+		return name, nil
+	}
+	return namespace + ":" + name, nil
+}
+
+// ToolIDsEqual reports whether a and b identify the same tool once both are
+// canonicalized as by CanonicalizeToolID, so "Docs:Search" and " docs:search"
+// are equal. It returns false if either ID is malformed.
+func ToolIDsEqual(a, b string) bool {
+	ca, err := CanonicalizeToolID(a)
+	if err != nil {
+		return false
+	}
+	cb, err := CanonicalizeToolID(b)
+	return err == nil && ca == cb
+}
+
+// parseToolIDLenient parses id like ParseToolID after trimming whitespace and
+// lowercasing, and additionally requires both components to use only
+// characters allowed in tool names.
+func parseToolIDLenient(id string) (namespace, name string, err error) {
+	namespace, name, err = ParseToolID(strings.ToLower(strings.TrimSpace(id)))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidToolID, id)
+	}
+	namespace, name = strings.TrimSpace(namespace), strings.TrimSpace(name)
+	if name == "" || (strings.Contains(id, ":") && namespace == "") {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidToolID, id)
+	}
+	for _, r := range namespace + name {
+		if !validToolNameRune(r) {
+			return "", "", fmt.Errorf("%w: %q contains %q", ErrInvalidToolID, id, r)
+		}
+	}
+	return namespace, name, nil
+}
+
+// hierarchySeparator separates path segments in hierarchical tool names
+// such as "team.service.tool".
+const hierarchySeparator = "."
+
+// SplitHierarchicalName splits a dotted tool name into its path segments.
+// Empty segments are dropped, so "a..b" yields ["a", "b"].
+func SplitHierarchicalName(name string) (path []string) {
+	for _, seg := range strings.Split(name, hierarchySeparator) {
+		if seg != "" {
+			path = append(path, seg)
+		}
+	}
+	return path
+}
+
+// JoinHierarchicalName joins path segments into a dotted tool name.
+// Empty segments are skipped.
+func JoinHierarchicalName(path ...string) string {
+	return strings.Join(SplitHierarchicalName(strings.Join(path, hierarchySeparator)), hierarchySeparator)
+}
+
+// NormalizeHierarchicalName moves the hierarchy encoded in a dotted Name into
+// Namespace, leaving only the leaf segment as Name. For example, a tool named
+// "team.service.tool" becomes Namespace "team.service" and Name "tool". An
+// existing Namespace is kept as the outermost prefix. Names without a dot are
+// left unchanged.
+func (t *Tool) NormalizeHierarchicalName() {
+	path := SplitHierarchicalName(t.Name)
+	if len(path) < 2 {
+		return
+	}
+	t.Namespace = JoinHierarchicalName(append([]string{t.Namespace}, path[:len(path)-1]...)...)
+	t.Name = path[len(path)-1]
+}
+
+// HasStructuredOutput reports whether the tool declares an OutputSchema.
+// Tools without one return unstructured content only. A nil value, a typed nil,
+// and an empty or JSON-null raw schema all count as no structured output.
+func (t *Tool) HasStructuredOutput() bool {
+	return schemaPresent(t.OutputSchema)
+}
+
+// schemaPresent reports whether schema holds a non-empty value in any of the
+// supported schema representations.
+func schemaPresent(schema any) bool {
+	switch s := schema.(type) {
+	case nil:
+		return false
+	case map[string]any:
+		return s != nil
+	case json.RawMessage:
+		return rawSchemaPresent(s)
+	case []byte:
+		return rawSchemaPresent(s)
+	case *jsonschema.Schema:
+		return s != nil
+	default:
+		return true
+	}
+}
+
+func rawSchemaPresent(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null"))
+}
+
+// ValidateOptions configures optional, stricter checks in
+// Tool.ValidateWithOptions. The zero value applies only the default checks
+// performed by Tool.Validate.
+type ValidateOptions struct {
+	// AllowedNamespaces restricts tools to the listed namespaces.
+	// An empty list means no restriction.
+	AllowedNamespaces []string
+	// AllowEmptyNamespace permits tools without a namespace when
+	// AllowedNamespaces is set. It has no effect otherwise.
+	AllowEmptyNamespace bool
+	// RejectDuplicateTags fails validation when NormalizeTags would drop any
+	// of the tool's tags, e.g. ["Foo", "foo"] or a tag with no valid
+	// characters, instead of silently deduplicating them.
+	RejectDuplicateTags bool
+	// LowercaseNamesOnly rejects tool names containing uppercase letters, as
+	// required by registries that want consistently lowercase names. Names
+	// are already limited to ASCII.
+	LowercaseNamesOnly bool
+	// RequireObjectOutput rejects an OutputSchema whose top-level "type" is
+	// not "object", since MCP structured output is always an object. Tools
+	// without an OutputSchema are unaffected.
+	RequireObjectOutput bool
+}
+
 // Validate checks basic invariants of Tool required by toolmodel consumers.
 // It does not validate JSON schemas; use SchemaValidator for that.
 func (t *Tool) Validate() error {
+	return t.ValidateWithOptions(ValidateOptions{})
+}
+
+// ValidateWithOptions performs the checks of Validate plus any stricter checks
+// enabled in opts. All failures wrap ErrInvalidTool.
+func (t *Tool) ValidateWithOptions(opts ValidateOptions) error {
 	if t.Name == "" {
 		return fmt.Errorf("%w: name is required", ErrInvalidTool)
 	}
@@ -206,12 +675,140 @@ func (t *Tool) Validate() error {
 	if len(invalidChars) > 0 {
 		return fmt.Errorf("%w: name contains invalid characters: %s", ErrInvalidTool, strings.Join(invalidChars, ", "))
 	}
-	if t.InputSchema == nil {
+	if !schemaPresent(t.InputSchema) {
 		return fmt.Errorf("%w: inputSchema is required", ErrInvalidTool)
 	}
+	if t.CostHint < 0 {
+		return fmt.Errorf("%w: costHint must be non-negative", ErrInvalidTool)
+	}
+	if t.RateLimitPerMinute < 0 {
+		return fmt.Errorf("%w: rateLimitPerMinute must be non-negative", ErrInvalidTool)
+	}
+	for i, b := range t.Backends {
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("%w: backends[%d]: %w", ErrInvalidTool, i, err)
+		}
+	}
+	if err := opts.checkName(t.Name); err != nil {
+		return err
+	}
+	if err := opts.checkNamespace(t.Namespace); err != nil {
+		return err
+	}
+	if err := opts.checkTags(t.Tags); err != nil {
+		return err
+	}
+	if err := opts.checkOutputSchema(t.OutputSchema); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateAgainstReserved rejects a tool whose Name matches one of the
+// reserved names, compared case-insensitively, so user tools cannot claim
+// names the runtime uses itself (e.g. "help", "list_tools", "cancel"). The
+// error wraps ErrInvalidTool.
+func (t *Tool) ValidateAgainstReserved(reserved []string) error {
+	for _, name := range reserved {
+		if strings.EqualFold(t.Name, name) {
+			return fmt.Errorf("%w: name %q is reserved", ErrInvalidTool, t.Name)
+		}
+	}
+	return nil
+}
+
+func (o ValidateOptions) checkName(name string) error {
+	if o.LowercaseNamesOnly && strings.ToLower(name) != name {
+		return fmt.Errorf("%w: name %q must be lowercase", ErrInvalidTool, name)
+	}
+	return nil
+}
+
+func (o ValidateOptions) checkOutputSchema(schema any) error {
+	if !o.RequireObjectOutput || !schemaPresent(schema) {
+		return nil
+	}
+	if _, ok := booleanSchema(schema); ok {
+		return fmt.Errorf("%w: outputSchema must have type \"object\", got a boolean schema", ErrInvalidTool)
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
+		return fmt.Errorf("%w: outputSchema: %w", ErrInvalidTool, err)
+	}
+	if types := schemaTypes(m); len(types) != 1 || types[0] != "object" {
+		return fmt.Errorf("%w: outputSchema must have type \"object\", got %v", ErrInvalidTool, m["type"])
+	}
 	return nil
 }
 
+func (o ValidateOptions) checkNamespace(namespace string) error {
+	if len(o.AllowedNamespaces) == 0 {
+		return nil
+	}
+	if namespace == "" {
+		if o.AllowEmptyNamespace {
+			return nil
+		}
+		return fmt.Errorf("%w: namespace is required", ErrInvalidTool)
+	}
+	for _, allowed := range o.AllowedNamespaces {
+		if namespace == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: namespace %q is not allowed", ErrInvalidTool, namespace)
+}
+
+func (o ValidateOptions) checkTags(tags []string) error {
+	if !o.RejectDuplicateTags || len(NormalizeTags(tags)) == len(tags) {
+		return nil
+	}
+	var problems []string
+	groups := make(map[string][]string)
+	var order []string
+	for _, raw := range tags {
+		normalized := NormalizeTags([]string{raw})
+		if len(normalized) == 0 {
+			problems = append(problems, fmt.Sprintf("tag %q is empty after normalization", raw))
+			continue
+		}
+		if _, ok := groups[normalized[0]]; !ok {
+			order = append(order, normalized[0])
+		}
+		groups[normalized[0]] = append(groups[normalized[0]], raw)
+	}
+	for _, tag := range order {
+		if raws := groups[tag]; len(raws) > 1 {
+			quoted := make([]string, len(raws))
+			for i, raw := range raws {
+				quoted[i] = strconv.Quote(raw)
+			}
+			problems = append(problems, fmt.Sprintf("tags %s collapse to %q", strings.Join(quoted, ", "), tag))
+		}
+	}
+	if len(problems) == 0 {
+		problems = append(problems, fmt.Sprintf("%d tags exceed the normalized tag limit", len(tags)))
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidTool, strings.Join(problems, "; "))
+}
+
+// CostHintOr returns CostHint, or def when no cost hint is specified.
+func (t *Tool) CostHintOr(def int) int {
+	if t.CostHint <= 0 {
+		return def
+	}
+	return t.CostHint
+}
+
+// RateLimitPerMinuteOr returns RateLimitPerMinute, or def when no rate limit
+// is specified.
+func (t *Tool) RateLimitPerMinuteOr(def int) int {
+	if t.RateLimitPerMinute <= 0 {
+		return def
+	}
+	return t.RateLimitPerMinute
+}
+
 // Validate checks basic invariants of ToolBackend.
 func (b ToolBackend) Validate() error {
 	switch b.Kind {
@@ -219,6 +816,9 @@ func (b ToolBackend) Validate() error {
 		if b.MCP == nil || b.MCP.ServerName == "" {
 			return fmt.Errorf("%w: MCP backend requires ServerName", ErrInvalidBackend)
 		}
+		if err := checkAuthScopes(b.MCP.AuthScopes); err != nil {
+			return err
+		}
 	case BackendKindProvider:
 		if b.Provider == nil {
 			return fmt.Errorf("%w: Provider backend requires Provider details", ErrInvalidBackend)
@@ -229,6 +829,9 @@ func (b ToolBackend) Validate() error {
 		if b.Provider.ToolID == "" {
 			return fmt.Errorf("%w: Provider backend requires ToolID", ErrInvalidBackend)
 		}
+		if err := checkAuthScopes(b.Provider.AuthScopes); err != nil {
+			return err
+		}
 	case BackendKindLocal:
 		if b.Local == nil || b.Local.Name == "" {
 			return fmt.Errorf("%w: Local backend requires Name", ErrInvalidBackend)
@@ -239,6 +842,47 @@ func (b ToolBackend) Validate() error {
 	return nil
 }
 
+func checkAuthScopes(scopes []string) error {
+	for i, scope := range scopes {
+		if strings.TrimSpace(scope) == "" {
+			return fmt.Errorf("%w: authScopes[%d] is empty", ErrInvalidBackend, i)
+		}
+	}
+	return nil
+}
+
+// authScopes returns the scopes declared by the backend's details.
+func (b ToolBackend) authScopes() []string {
+	switch b.Kind {
+	case BackendKindMCP:
+		if b.MCP != nil {
+			return b.MCP.AuthScopes
+		}
+	case BackendKindProvider:
+		if b.Provider != nil {
+			return b.Provider.AuthScopes
+		}
+	}
+	return nil
+}
+
+// RequiredScopes returns the union of the AuthScopes declared by the tool's
+// Backends, deduplicated and sorted.
+func (t *Tool) RequiredScopes() []string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, b := range t.Backends {
+		for _, scope := range b.authScopes() {
+			if scope != "" && !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
 func validToolNameRune(r rune) bool {
 	return (r >= 'a' && r <= 'z') ||
 		(r >= 'A' && r <= 'Z') ||
@@ -247,17 +891,356 @@ func validToolNameRune(r rune) bool {
 }
 
 // ToMCPJSON serializes the Tool to JSON that is compatible with the MCP Tool spec.
-// This strips toolmodel-specific fields (Namespace, Version) and returns only
-// the standard MCP Tool fields.
+// This strips toolmodel-specific extension fields (such as Namespace, Version,
+// and Tags) and returns only the standard MCP Tool fields.
 func (t *Tool) ToMCPJSON() ([]byte, error) {
 	return json.Marshal(t.Tool)
 }
 
+// MetaKeyBackends is the "_meta" key under which ToMCPJSONWithBackends stores
+// a tool's Backends.
+const MetaKeyBackends = "io.github.jonwraymond.toolmodel/backends"
+
+// ToMCPJSONWithBackends serializes the Tool like ToMCPJSON but keeps its
+// Backends in "_meta" under MetaKeyBackends, so a tool proxied through an MCP
+// server can be restored with FromMCPJSONWithBackends. Other "_meta" entries
+// are kept; a tool without backends encodes exactly like ToMCPJSON. t is not
+// modified.
+func (t *Tool) ToMCPJSONWithBackends() ([]byte, error) {
+	if len(t.Backends) == 0 {
+		return t.ToMCPJSON()
+	}
+	c := t.Tool
+	c.Meta = maps.Clone(t.Meta)
+	if c.Meta == nil {
+		c.Meta = mcp.Meta{}
+	}
+	c.Meta[MetaKeyBackends] = t.Backends
+	return json.Marshal(c)
+}
+
+// InputSchemaJSON returns the tool's InputSchema alone as compact JSON,
+// whatever its stored representation, e.g. for the parameters of an LLM
+// function-calling API. A missing InputSchema yields {"type":"object"}.
+func (t *Tool) InputSchemaJSON() ([]byte, error) {
+	if !schemaPresent(t.InputSchema) {
+		return []byte(`{"type":"object"}`), nil
+	}
+	return compactSchemaJSON(t.InputSchema)
+}
+
+// OutputSchemaJSON returns the tool's OutputSchema alone as compact JSON, or
+// nil when the tool declares none.
+func (t *Tool) OutputSchemaJSON() ([]byte, error) {
+	if !t.HasStructuredOutput() {
+		return nil, nil
+	}
+	return compactSchemaJSON(t.OutputSchema)
+}
+
+// compactSchemaJSON encodes schema as compact JSON. Raw-byte schemas keep
+// their key order.
+func compactSchemaJSON(schema any) ([]byte, error) {
+	var raw []byte
+	switch s := schema.(type) {
+	case json.RawMessage:
+		raw = s
+	case []byte:
+		raw = s
+	default:
+		data, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to marshal schema: %v", ErrInvalidSchema, err)
+		}
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return buf.Bytes(), nil
+}
+
 // ToJSON serializes the full Tool (including toolmodel extensions) to JSON.
 func (t *Tool) ToJSON() ([]byte, error) {
 	return json.Marshal(t)
 }
 
+// ToJSONWithSchemaDialect serializes the full Tool like ToJSON, with the
+// "$schema" of the input and output schemas set to dialect, for clients that
+// only understand one dialect. dialect must be SchemaDialect202012,
+// SchemaDialectDraft07 or SchemaDialectDraft07Alt; other values return
+// ErrUnsupportedSchema. Boolean schemas have no "$schema" and are emitted
+// unchanged. t is not modified.
+func (t *Tool) ToJSONWithSchemaDialect(dialect string) ([]byte, error) {
+	switch dialect {
+	case SchemaDialect202012, SchemaDialectDraft07, SchemaDialectDraft07Alt:
+	default:
+		return nil, fmt.Errorf("%w: %s (only 2020-12 and draft-07 are supported)", ErrUnsupportedSchema, dialect)
+	}
+	c := *t
+	for _, schema := range []*any{&c.InputSchema, &c.OutputSchema} {
+		if !schemaPresent(*schema) {
+			continue
+		}
+		if _, ok := booleanSchema(*schema); ok {
+			continue
+		}
+		m, err := schemaToMap(*schema)
+		if err != nil {
+			return nil, err
+		}
+		m["$schema"] = dialect
+		*schema = m
+	}
+	return c.ToJSON()
+}
+
+// ToJSONProjection serializes only the named top-level JSON fields of the full
+// Tool, e.g. "name", "namespace", "tags". Unknown or empty fields are omitted.
+func (t *Tool) ToJSONProjection(fields ...string) ([]byte, error) {
+	all, err := t.jsonFields()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f]; ok {
+			out[f] = v
+		}
+	}
+	return json.Marshal(out)
+}
+
+// ToSummaryJSON serializes the full Tool without its input and output
+// schemas, for listings where schemas would dominate the payload.
+func (t *Tool) ToSummaryJSON() ([]byte, error) {
+	all, err := t.jsonFields()
+	if err != nil {
+		return nil, err
+	}
+	delete(all, "inputSchema")
+	delete(all, "outputSchema")
+	return json.Marshal(all)
+}
+
+// jsonFields returns the full Tool JSON split into its top-level fields.
+func (t *Tool) jsonFields() (map[string]json.RawMessage, error) {
+	data, err := t.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Fingerprint returns a stable hex-encoded SHA-256 digest of the full Tool,
+// including toolmodel extensions. It is computed over canonical JSON (sorted
+// keys, normalized numbers), so map ordering and numeric representation
+// (10 vs 10.0) do not affect it. Numbers are decoded as by DeterministicJSON,
+// so integers that differ only beyond float64 precision give different
+// fingerprints.
+func (t *Tool) Fingerprint() (string, error) {
+	v, err := t.canonicalJSONValue()
+	if err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DeterministicJSON serializes the full Tool, like ToJSON, into bytes that
+// stay stable across Go versions, for embedding in signatures. Object keys
+// are sorted at every level, including inside schemas; numbers are decoded
+// without loss, so integers beyond 2^53 keep every digit, then normalized as
+// by CanonicalizeSchema and formatted with strconv; and
+// strings are written without HTML escaping, so "<", ">" and "&" appear
+// literally. The output is compact with no trailing newline.
+func (t *Tool) DeterministicJSON() ([]byte, error) {
+	v, err := t.canonicalJSONValue()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeDeterministicJSON(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalJSONValue decodes the full Tool JSON with numbers kept exact and
+// then normalized, the shared input of Fingerprint and DeterministicJSON.
+func (t *Tool) canonicalJSONValue() (any, error) {
+	data, err := t.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(v), nil
+}
+
+// writeDeterministicJSON writes decoded JSON with sorted keys, strconv number
+// formatting and unescaped HTML characters.
+func writeDeterministicJSON(buf *bytes.Buffer, v any) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(val))
+	case int64:
+		buf.WriteString(strconv.FormatInt(val, 10))
+	case float64:
+		buf.WriteString(strconv.FormatFloat(val, 'g', -1, 64))
+	case json.Number:
+		buf.WriteString(val.String()) // integer beyond int64, or beyond float64 range
+	case string:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(val); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline
+	case []any:
+		buf.WriteByte('[')
+		for i, e := range val {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeDeterministicJSON(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		buf.WriteByte('{')
+		for i, k := range sortedKeys(val) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeDeterministicJSON(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeDeterministicJSON(buf, val[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected JSON value of type %T", v)
+	}
+	return nil
+}
+
+// String returns a deterministic multi-line description of the tool for
+// golden-file tests and code review: the ID, version, sorted tags,
+// description, and the input and output schemas as indented canonical JSON.
+// Empty version and tags lines are omitted.
+func (t *Tool) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tool %s\n", t.ToolID())
+	if t.Version != "" {
+		fmt.Fprintf(&b, "version: %s\n", t.Version)
+	}
+	if len(t.Tags) > 0 {
+		tags := append([]string(nil), t.Tags...)
+		sort.Strings(tags)
+		fmt.Fprintf(&b, "tags: %s\n", strings.Join(tags, ", "))
+	}
+	fmt.Fprintf(&b, "description: %s\n", t.Description)
+	writeSchemaString(&b, "inputSchema", t.InputSchema)
+	if schemaPresent(t.OutputSchema) {
+		writeSchemaString(&b, "outputSchema", t.OutputSchema)
+	}
+	return b.String()
+}
+
+func writeSchemaString(b *strings.Builder, label string, schema any) {
+	if !schemaPresent(schema) {
+		fmt.Fprintf(b, "%s: none\n", label)
+		return
+	}
+	canonical, err := CanonicalizeSchema(schema)
+	if err != nil {
+		fmt.Fprintf(b, "%s: invalid (%v)\n", label, err)
+		return
+	}
+	data, err := json.MarshalIndent(canonical, "  ", "  ")
+	if err != nil {
+		fmt.Fprintf(b, "%s: invalid (%v)\n", label, err)
+		return
+	}
+	fmt.Fprintf(b, "%s:\n  %s\n", label, data)
+}
+
+// FunctionallyEqual reports whether t and other describe the same callable
+// tool, ignoring metadata such as Namespace, Version, Tags, and annotations.
+// Only Name, Description, and the canonical forms of InputSchema and
+// OutputSchema are compared, so schemas in different representations (map,
+// raw JSON, *jsonschema.Schema) or with 10 vs 10.0 still match.
+func (t *Tool) FunctionallyEqual(other *Tool) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return t.Name == other.Name &&
+		t.Description == other.Description &&
+		schemasEqual(t.InputSchema, other.InputSchema) &&
+		schemasEqual(t.OutputSchema, other.OutputSchema)
+}
+
+// Equal reports whether t and other are structurally the same tool, for
+// deduplicating tools that arrive from several registries. It extends
+// FunctionallyEqual with Namespace, Version and the normalized Tags, compared
+// without regard to order or duplicates. Like FunctionallyEqual, schemas are
+// compared by canonical JSON, so key order and storage representation do not
+// matter. Two nil tools are equal; a nil tool is not equal to a non-nil one.
+func (t *Tool) Equal(other *Tool) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return t.Namespace == other.Namespace &&
+		t.Version == other.Version &&
+		slices.Equal(sortedTags(t.Tags), sortedTags(other.Tags)) &&
+		t.FunctionallyEqual(other)
+}
+
+// sortedTags returns the normalized tags in sorted order.
+func sortedTags(tags []string) []string {
+	out := NormalizeTags(tags)
+	sort.Strings(out)
+	return out
+}
+
+// schemasEqual compares two schemas by their canonical JSON. Absent schemas
+// are equal to each other; schemas that cannot be canonicalized are never
+// equal to anything.
+func schemasEqual(a, b any) bool {
+	if !schemaPresent(a) || !schemaPresent(b) {
+		return !schemaPresent(a) && !schemaPresent(b)
+	}
+	ca, err := CanonicalJSON(a)
+	if err != nil {
+		return false
+	}
+	cb, err := CanonicalJSON(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ca, cb)
+}
+
 // FromMCPJSON deserializes an MCP Tool JSON into a Tool struct.
 // The Namespace and Version fields will be empty after this call.
 func FromMCPJSON(data []byte) (*Tool, error) {
@@ -268,6 +1251,28 @@ func FromMCPJSON(data []byte) (*Tool, error) {
 	return &Tool{Tool: mcpTool}, nil
 }
 
+// FromMCPJSONWithBackends deserializes MCP Tool JSON like FromMCPJSON and
+// restores Backends stored under MetaKeyBackends by ToMCPJSONWithBackends,
+// removing that key from Meta. Malformed backend metadata is an error.
+func FromMCPJSONWithBackends(data []byte) (*Tool, error) {
+	tool, err := FromMCPJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := tool.Meta[MetaKeyBackends]
+	if !ok {
+		return tool, nil
+	}
+	if err := jsonRoundTrip(raw, &tool.Backends); err != nil {
+		return nil, fmt.Errorf("%w: _meta %q: %v", ErrInvalidBackend, MetaKeyBackends, err)
+	}
+	delete(tool.Meta, MetaKeyBackends)
+	if len(tool.Meta) == 0 {
+		tool.Meta = nil
+	}
+	return tool, nil
+}
+
 // FromJSON deserializes a full Tool JSON (including toolmodel extensions) into a Tool struct.
 func FromJSON(data []byte) (*Tool, error) {
 	var tool Tool
@@ -0,0 +1,493 @@
+package toolmodel
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// UnexpectedInputFields returns the keys of args that are not declared in the
+// top-level "properties" of the tool's InputSchema, sorted alphabetically.
+// The check is advisory: it ignores "additionalProperties", so it reports
+// undeclared keys even when the schema would accept them.
+func (t *Tool) UnexpectedInputFields(args map[string]any) ([]string, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	props := schemaProperties(schema)
+	var extra []string
+	for key := range args {
+		if _, ok := props[key]; !ok {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	return extra, nil
+}
+
+// InvalidArgumentKeys returns the keys of args that the top-level
+// "propertyNames" constraint of the tool's InputSchema rejects, sorted
+// alphabetically, so callers can report the offending keys instead of a
+// generic validation failure. References inside "propertyNames" resolve
+// against the whole InputSchema. It returns nil when the schema declares no
+// "propertyNames".
+func (t *Tool) InvalidArgumentKeys(args map[string]any) ([]string, error) {
+	_, ix, err := NewDefaultValidator().prepare(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	root := ix.root
+	if _, ok := root["propertyNames"]; !ok {
+		return nil, nil
+	}
+	c, err := newViolationCollector(root)
+	if err != nil {
+		return nil, err
+	}
+	names := c.ref("/propertyNames")
+	var invalid []string
+	for key := range args {
+		if err := c.check(names, key); err != nil {
+			invalid = append(invalid, key)
+		}
+	}
+	sort.Strings(invalid)
+	return invalid, nil
+}
+
+// InputPropertyCountBounds reads the top-level "minProperties" and
+// "maxProperties" keywords of the tool's InputSchema. hasMin and hasMax report
+// whether each bound is declared.
+func (t *Tool) InputPropertyCountBounds() (min, max int, hasMin, hasMax bool, err error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return 0, 0, false, false, err
+	}
+	min, hasMin = schemaInt(schema, "minProperties")
+	max, hasMax = schemaInt(schema, "maxProperties")
+	return min, max, hasMin, hasMax, nil
+}
+
+// CallableWithoutArgs reports whether the tool can be called with no
+// arguments: its InputSchema declares no required properties and accepts an
+// empty object. Tools whose InputSchema cannot be parsed report false.
+func (t *Tool) CallableWithoutArgs() bool {
+	required, err := t.requiredInputs()
+	if err != nil || len(required) > 0 {
+		return false
+	}
+	return NewDefaultValidator().ValidateInput(t, map[string]any{}) == nil
+}
+
+// requiredInputs returns the top-level required properties of the tool's
+// InputSchema in declared order.
+func (t *Tool) requiredInputs() ([]string, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return schemaRequired(schema), nil
+}
+
+// RequiredParams returns the names listed in the "required" keyword of the
+// tool's InputSchema, in declared order, whatever the schema's stored
+// representation. A schema without "required" has no required parameters.
+// It returns an error wrapping ErrInvalidSchema when the InputSchema is not an
+// object schema.
+func (t *Tool) RequiredParams() ([]string, error) {
+	schema, err := t.objectInputSchema()
+	if err != nil {
+		return nil, err
+	}
+	return schemaRequired(schema), nil
+}
+
+// OptionalParams returns the names of the top-level properties declared by
+// the tool's InputSchema that are not required, sorted alphabetically. Errors
+// are as for RequiredParams.
+func (t *Tool) OptionalParams() ([]string, error) {
+	schema, err := t.objectInputSchema()
+	if err != nil {
+		return nil, err
+	}
+	required := make(map[string]bool)
+	for _, name := range schemaRequired(schema) {
+		required[name] = true
+	}
+	optional := []string{}
+	for _, name := range sortedKeys(schemaProperties(schema)) {
+		if !required[name] {
+			optional = append(optional, name)
+		}
+	}
+	return optional, nil
+}
+
+// MissingRequiredDeep returns JSON Pointers to the required fields absent
+// from args, for forms with nested object parameters. Missing top-level
+// fields are reported first (e.g. "/name"), in declared order; then, for each
+// top-level property supplied as an object, the missing required fields of
+// its subschema (e.g. "/address/zip"), by property name. The check goes one
+// level deep: fields of objects nested further are not inspected, and an
+// object that is itself missing is reported without its fields. Local "$ref"s
+// are followed. It returns nil when nothing is missing.
+func (t *Tool) MissingRequiredDeep(args map[string]any) ([]string, error) {
+	_, ix, err := NewDefaultValidator().prepare(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	root := ix.root
+	var missing []string
+	for _, name := range schemaRequired(root) {
+		if _, ok := args[name]; !ok {
+			missing = append(missing, "/"+escapeJSONPointer(name))
+		}
+	}
+	props := schemaProperties(root)
+	for _, name := range sortedKeys(props) {
+		nested, ok := args[name].(map[string]any)
+		if _, isMap := props[name].(map[string]any); !ok || !isMap {
+			continue
+		}
+		_, prop := derefLocal(ix, "/properties/"+escapeJSONPointer(name))
+		for _, field := range schemaRequired(prop) {
+			if _, ok := nested[field]; !ok {
+				missing = append(missing, "/"+escapeJSONPointer(name)+"/"+escapeJSONPointer(field))
+			}
+		}
+	}
+	return missing, nil
+}
+
+// objectInputSchema returns the InputSchema as a map, rejecting schemas that
+// are not JSON objects or whose "type" excludes "object".
+func (t *Tool) objectInputSchema() (map[string]any, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !schemaAllowsType(schema, "object") {
+		return nil, fmt.Errorf("%w: inputSchema type %v is not an object", ErrInvalidSchema, schema["type"])
+	}
+	return schema, nil
+}
+
+// InputPropertyFormats returns the "format" of each top-level string property
+// of the tool's InputSchema that declares one, keyed by property name. It is
+// intended for UI widget selection (date pickers, URL fields). The
+// DefaultValidator asserts formats only when WithFormatAssertion(true) is set.
+func (t *Tool) InputPropertyFormats() (map[string]string, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	formats := make(map[string]string)
+	for name, raw := range schemaProperties(schema) {
+		prop, ok := raw.(map[string]any)
+		if !ok || !schemaAllowsType(prop, "string") {
+			continue
+		}
+		if format, ok := prop["format"].(string); ok && format != "" {
+			formats[name] = format
+		}
+	}
+	return formats, nil
+}
+
+// ValidateField validates value against the subschema of the top-level input
+// property named property, for checking a single form field as the user edits
+// it. References inside the property's subschema resolve against the whole
+// InputSchema. It returns ErrInvalidSchema if the InputSchema does not declare
+// the property.
+func (t *Tool) ValidateField(property string, value any) error {
+	v := NewDefaultValidator()
+	_, ix, err := v.prepare(t.InputSchema)
+	if err != nil {
+		return err
+	}
+	root := ix.root
+	if _, ok := schemaProperties(root)[property]; !ok {
+		return fmt.Errorf("%w: property %q is not declared", ErrInvalidSchema, property)
+	}
+	var instance any
+	if err := jsonRoundTrip(value, &instance); err != nil {
+		return fmt.Errorf("validation failed: %v", err)
+	}
+	c, err := newViolationCollector(root)
+	if err != nil {
+		return err
+	}
+	if err := c.check(c.ref("/properties/"+escapeJSONPointer(property)), instance); err != nil {
+		return fmt.Errorf("validation failed: %s: %w", property, err)
+	}
+	return nil
+}
+
+// ValidateWithUpdate is DefaultValidator.ValidateUpdate using a validator
+// configured with opts.
+func (t *Tool) ValidateWithUpdate(current map[string]any, pointer string, value any, opts ...ValidatorOption) (map[string]any, error) {
+	return NewDefaultValidator(opts...).ValidateUpdate(t, current, pointer, value)
+}
+
+// NormalizeSchemaDialect returns a clone of t whose input and output schemas
+// are rewritten to JSON Schema 2020-12, so a catalog imported from mixed
+// sources uses one dialect. A draft-07 "$schema" is cleared, boolean
+// "exclusiveMinimum"/"exclusiveMaximum" become numeric, "definitions" move to
+// "$defs", tuple "items" become "prefixItems" and "dependencies" split into
+// "dependentRequired" and "dependentSchemas". Rewritten schemas are stored as
+// map[string]any; absent and boolean schemas are kept as they are. An
+// unsupported "$schema" returns ErrUnsupportedSchema, and a name defined
+// differently in "definitions" and "$defs" returns ErrInvalidSchema. t is not
+// modified.
+func (t *Tool) NormalizeSchemaDialect() (*Tool, error) {
+	c := t.Clone()
+	for _, schema := range []*any{&c.InputSchema, &c.OutputSchema} {
+		if !schemaPresent(*schema) {
+			continue
+		}
+		if _, ok := booleanSchema(*schema); ok {
+			continue
+		}
+		m, err := upgradeToDraft202012(*schema)
+		if err != nil {
+			return nil, err
+		}
+		*schema = m
+	}
+	return c, nil
+}
+
+// ParamSchema returns the subschema of the top-level input property name and
+// whether the InputSchema declares it, whatever the schema's stored
+// representation. The returned map is a copy that may be modified. A boolean
+// subschema is returned in object form: {} for true and {"not": {}} for
+// false. An error is returned only when the InputSchema cannot be parsed.
+func (t *Tool) ParamSchema(name string) (map[string]any, bool, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, false, err
+	}
+	switch prop := schemaProperties(schema)[name].(type) {
+	case map[string]any:
+		return prop, true, nil
+	case bool:
+		if prop {
+			return map[string]any{}, true, nil
+		}
+		return map[string]any{"not": map[string]any{}}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// constraintKeywords are the keywords PropertyConstraints reports.
+var constraintKeywords = []string{
+	"const", "enum", "exclusiveMaximum", "exclusiveMinimum", "format",
+	"maxItems", "maxLength", "maximum", "minItems", "minLength", "minimum",
+	"multipleOf", "pattern", "uniqueItems",
+}
+
+// PropertyConstraints returns the validation keywords (minLength, maxLength,
+// minimum, maximum, pattern, enum, format and similar) declared directly on
+// the subschema of the top-level input property named property, keyed by
+// keyword, for generating client-side form validation. Constraints reached
+// through $ref or combinators are not included. It returns ErrInvalidSchema
+// if the InputSchema does not declare the property.
+func (t *Tool) PropertyConstraints(property string) (map[string]any, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := schemaProperties(schema)[property]
+	if !ok {
+		return nil, fmt.Errorf("%w: property %q is not declared", ErrInvalidSchema, property)
+	}
+	constraints := make(map[string]any)
+	prop, ok := raw.(map[string]any)
+	if !ok {
+		return constraints, nil
+	}
+	for _, keyword := range constraintKeywords {
+		if v, ok := prop[keyword]; ok {
+			constraints[keyword] = v
+		}
+	}
+	return constraints, nil
+}
+
+// EnumChunks returns the "enum" values of the top-level input property named
+// property split into consecutive chunks of at most size values, for clients
+// that page through large choice lists. Values are returned as decoded JSON
+// (numbers are float64). It returns ErrInvalidSchema if the property is not
+// declared or declares no enum.
+func (t *Tool) EnumChunks(property string, size int) ([][]any, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive, got %d", size)
+	}
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := schemaProperties(schema)[property]
+	if !ok {
+		return nil, fmt.Errorf("%w: property %q is not declared", ErrInvalidSchema, property)
+	}
+	prop, _ := raw.(map[string]any)
+	enum, ok := prop["enum"].([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: property %q has no enum", ErrInvalidSchema, property)
+	}
+	var chunks [][]any
+	for len(enum) > size {
+		chunks = append(chunks, enum[:size:size])
+		enum = enum[size:]
+	}
+	if len(enum) > 0 {
+		chunks = append(chunks, enum)
+	}
+	return chunks, nil
+}
+
+// ArgumentTemplate returns an editable argument object for the tool with
+// every top-level input property present, for scaffolding a call in a UI.
+// Each value is the property's "const", else its "default", else its first
+// "enum" value, else a zero placeholder for its first non-null type: "" for
+// strings, 0 for numbers and integers, false, [] or {}. Properties without a
+// usable type get null, as do required fields that are not declared under
+// "properties", so every required field is present.
+func (t *Tool) ArgumentTemplate() (map[string]any, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	template := make(map[string]any)
+	for name, raw := range schemaProperties(schema) {
+		prop, _ := raw.(map[string]any)
+		template[name] = placeholderValue(prop)
+	}
+	for _, name := range schemaRequired(schema) {
+		if _, ok := template[name]; !ok {
+			template[name] = nil
+		}
+	}
+	return template, nil
+}
+
+// placeholderValue picks the ArgumentTemplate value for a property schema.
+func placeholderValue(prop map[string]any) any {
+	if v, ok := prop["const"]; ok {
+		return v
+	}
+	if v, ok := prop["default"]; ok {
+		return v
+	}
+	if enum, ok := prop["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, typ := range schemaTypes(prop) {
+		switch typ {
+		case "string":
+			return ""
+		case "integer", "number":
+			return float64(0)
+		case "boolean":
+			return false
+		case "array":
+			return []any{}
+		case "object":
+			return map[string]any{}
+		}
+	}
+	return nil
+}
+
+// InputPropertyTitles returns the "title" of each top-level property of the
+// tool's InputSchema that declares a non-empty one, keyed by property name.
+// Together with DisplayName it lets UIs prefer author-supplied labels over
+// generated ones.
+func (t *Tool) InputPropertyTitles() (map[string]string, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]string)
+	for name, raw := range schemaProperties(schema) {
+		prop, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if title, ok := prop["title"].(string); ok && title != "" {
+			titles[name] = title
+		}
+	}
+	return titles, nil
+}
+
+// DeprecatedInputs returns the top-level properties of the tool's InputSchema
+// whose subschema is marked "deprecated": true, sorted alphabetically.
+// Deprecation is an annotation: the DefaultValidator still accepts the
+// properties.
+func (t *Tool) DeprecatedInputs() ([]string, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	var deprecated []string
+	for name, raw := range schemaProperties(schema) {
+		if prop, ok := raw.(map[string]any); ok && prop["deprecated"] == true {
+			deprecated = append(deprecated, name)
+		}
+	}
+	sort.Strings(deprecated)
+	return deprecated, nil
+}
+
+// Thresholds used by SchemaComplexityWarnings.
+const (
+	// MaxRecommendedCombinatorDepth is the deepest nesting of allOf/anyOf/oneOf
+	// that is not reported.
+	MaxRecommendedCombinatorDepth = 2
+	// MaxRecommendedEnumValues is the largest enum that is not reported.
+	MaxRecommendedEnumValues = 100
+)
+
+var combinatorPathPattern = regexp.MustCompile(`/(allOf|anyOf|oneOf)/[0-9]+`)
+
+// SchemaComplexityWarnings reports constructs in the tool's InputSchema that
+// the MCP spec discourages because they complicate client handling:
+// combinators nested deeper than MaxRecommendedCombinatorDepth, any "$ref",
+// and enums with more than MaxRecommendedEnumValues values. Each warning is
+// prefixed with the JSON Pointer of the offending node. The warnings are
+// advisory; the schema remains valid.
+func (t *Tool) SchemaComplexityWarnings() ([]string, error) {
+	schema, err := schemaToMap(t.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	walkSchema(schema, func(path string, node map[string]any) bool {
+		at := path
+		if at == "" {
+			at = "/"
+		}
+		for _, kw := range []string{"allOf", "anyOf", "oneOf"} {
+			if _, ok := node[kw].([]any); !ok {
+				continue
+			}
+			depth := len(combinatorPathPattern.FindAllString(path, -1)) + 1
+			if depth > MaxRecommendedCombinatorDepth {
+				warnings = append(warnings, fmt.Sprintf("%s: combinators nested %d deep (more than %d)", at, depth, MaxRecommendedCombinatorDepth))
+			}
+			break
+		}
+		if ref, ok := node["$ref"].(string); ok {
+			warnings = append(warnings, fmt.Sprintf("%s: uses $ref %q", at, ref))
+		}
+		if enum, ok := node["enum"].([]any); ok && len(enum) > MaxRecommendedEnumValues {
+			warnings = append(warnings, fmt.Sprintf("%s: enum has %d values (more than %d)", at, len(enum), MaxRecommendedEnumValues))
+		}
+		return true
+	})
+	return warnings, nil
+}
@@ -0,0 +1,882 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestTool_UnexpectedInputFields(t *testing.T) {
+	schemas := map[string]any{
+		"map": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string"},
+			},
+		},
+		"raw message": json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"}}}`),
+	}
+
+	for name, schema := range schemas {
+		t.Run(name, func(t *testing.T) {
+			tool := Tool{Tool: mcp.Tool{Name: "search", InputSchema: schema}}
+			got, err := tool.UnexpectedInputFields(map[string]any{"query": "go", "limt": 5, "debug": true})
+			if err != nil {
+				t.Fatalf("UnexpectedInputFields() error = %v", err)
+			}
+			want := []string{"debug", "limt"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("UnexpectedInputFields() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("no extras", func(t *testing.T) {
+		tool := Tool{Tool: mcp.Tool{Name: "search", InputSchema: schemas["map"]}}
+		got, err := tool.UnexpectedInputFields(map[string]any{"query": "go"})
+		if err != nil || len(got) != 0 {
+			t.Errorf("UnexpectedInputFields() = %v, %v, want none", got, err)
+		}
+	})
+
+	t.Run("nil schema", func(t *testing.T) {
+		tool := Tool{Tool: mcp.Tool{Name: "search"}}
+		if _, err := tool.UnexpectedInputFields(nil); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("UnexpectedInputFields() error = %v, want ErrInvalidSchema", err)
+		}
+	})
+}
+
+func TestTool_InvalidArgumentKeys(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "env", InputSchema: map[string]any{
+		"type":          "object",
+		"propertyNames": map[string]any{"pattern": "^[A-Z_][A-Z0-9_]*$", "maxLength": 8},
+	}}}
+
+	got, err := tool.InvalidArgumentKeys(map[string]any{
+		"HOME":        "/root",
+		"PATH":        "/bin",
+		"lower":       "x",
+		"1ST":         "y",
+		"TOO_LONG_ID": "z",
+	})
+	if err != nil {
+		t.Fatalf("InvalidArgumentKeys() error = %v", err)
+	}
+	if want := []string{"1ST", "TOO_LONG_ID", "lower"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InvalidArgumentKeys() = %v, want %v", got, want)
+	}
+
+	open := &Tool{Tool: mcp.Tool{Name: "open", InputSchema: map[string]any{"type": "object"}}}
+	if got, err := open.InvalidArgumentKeys(map[string]any{"anything": 1}); err != nil || got != nil {
+		t.Errorf("InvalidArgumentKeys() without propertyNames = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestTool_InputPropertyCountBounds(t *testing.T) {
+	schema := map[string]any{
+		"type":          "object",
+		"minProperties": 1,
+		"maxProperties": 2,
+	}
+	tool := Tool{Tool: mcp.Tool{Name: "bounded", InputSchema: schema}}
+
+	min, max, hasMin, hasMax, err := tool.InputPropertyCountBounds()
+	if err != nil {
+		t.Fatalf("InputPropertyCountBounds() error = %v", err)
+	}
+	if min != 1 || max != 2 || !hasMin || !hasMax {
+		t.Errorf("InputPropertyCountBounds() = (%d, %d, %v, %v), want (1, 2, true, true)", min, max, hasMin, hasMax)
+	}
+
+	v := NewDefaultValidator()
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr bool
+	}{
+		{name: "too few", args: map[string]any{}, wantErr: true},
+		{name: "within bounds", args: map[string]any{"a": 1, "b": 2}},
+		{name: "too many", args: map[string]any{"a": 1, "b": 2, "c": 3}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateInput(&tool, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("unbounded", func(t *testing.T) {
+		open := Tool{Tool: mcp.Tool{Name: "open", InputSchema: map[string]any{"type": "object"}}}
+		_, _, hasMin, hasMax, err := open.InputPropertyCountBounds()
+		if err != nil || hasMin || hasMax {
+			t.Errorf("InputPropertyCountBounds() = hasMin %v, hasMax %v, err %v, want no bounds", hasMin, hasMax, err)
+		}
+	})
+}
+
+func TestTool_CallableWithoutArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		want   bool
+	}{
+		{
+			name:   "no parameters",
+			schema: map[string]any{"type": "object", "additionalProperties": false},
+			want:   true,
+		},
+		{
+			name: "optional fields only",
+			schema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"limit": map[string]any{"type": "integer"}},
+			},
+			want: true,
+		},
+		{
+			name:   "required field",
+			schema: json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"}},"required":["query"]}`),
+			want:   false,
+		},
+		{
+			name:   "minProperties",
+			schema: map[string]any{"type": "object", "minProperties": 1},
+			want:   false,
+		},
+		{
+			name:   "missing schema",
+			schema: nil,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := Tool{Tool: mcp.Tool{Name: "t", InputSchema: tt.schema}}
+			if got := tool.CallableWithoutArgs(); got != tt.want {
+				t.Errorf("CallableWithoutArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_RequiredOptionalParams(t *testing.T) {
+	tests := []struct {
+		name         string
+		schema       any
+		wantRequired []string
+		wantOptional []string
+		wantErr      bool
+	}{
+		{
+			name: "map",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"query": map[string]any{"type": "string"},
+					"limit": map[string]any{"type": "integer"},
+					"lang":  map[string]any{"type": "string"},
+				},
+				"required": []any{"query", "lang"},
+			},
+			wantRequired: []string{"query", "lang"},
+			wantOptional: []string{"limit"},
+		},
+		{
+			name:         "raw JSON without required",
+			schema:       json.RawMessage(`{"type":"object","properties":{"b":{},"a":{}}}`),
+			wantRequired: []string{},
+			wantOptional: []string{"a", "b"},
+		},
+		{
+			name:         "bytes",
+			schema:       []byte(`{"properties":{"q":{}},"required":["q"]}`),
+			wantRequired: []string{"q"},
+			wantOptional: []string{},
+		},
+		{name: "non-object type", schema: map[string]any{"type": "array"}, wantErr: true},
+		{name: "boolean schema", schema: json.RawMessage(`true`), wantErr: true},
+		{name: "missing schema", schema: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: tt.schema}}
+			required, err := tool.RequiredParams()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequiredParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			optional, optErr := tool.OptionalParams()
+			if (optErr != nil) != tt.wantErr {
+				t.Fatalf("OptionalParams() error = %v, wantErr %v", optErr, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSchema) {
+					t.Errorf("RequiredParams() error = %v, want ErrInvalidSchema", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(required, tt.wantRequired) {
+				t.Errorf("RequiredParams() = %v, want %v", required, tt.wantRequired)
+			}
+			if !reflect.DeepEqual(optional, tt.wantOptional) {
+				t.Errorf("OptionalParams() = %v, want %v", optional, tt.wantOptional)
+			}
+		})
+	}
+}
+
+func TestTool_MissingRequiredDeep(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "ship", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"address": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"street": map[string]any{"type": "string"},
+					"zip":    map[string]any{"type": "string"},
+					"geo": map[string]any{
+						"type":     "object",
+						"required": []any{"lat"},
+					},
+				},
+				"required": []any{"street", "zip"},
+			},
+			"billing": map[string]any{"$ref": "#/$defs/card"},
+		},
+		"required": []any{"name", "address"},
+		"$defs": map[string]any{
+			"card": map[string]any{"type": "object", "required": []any{"number"}},
+		},
+	}}}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []string
+	}{
+		{
+			name: "nested field missing",
+			args: map[string]any{"name": "Ada", "address": map[string]any{"street": "Main St"}},
+			want: []string{"/address/zip"},
+		},
+		{
+			name: "top-level and nested",
+			args: map[string]any{"address": map[string]any{}, "billing": map[string]any{}},
+			want: []string{"/name", "/address/street", "/address/zip", "/billing/number"},
+		},
+		{
+			name: "missing object reported without its fields",
+			args: map[string]any{"name": "Ada"},
+			want: []string{"/address"},
+		},
+		{
+			name: "deeper levels not inspected",
+			args: map[string]any{"name": "Ada", "address": map[string]any{"street": "Main St", "zip": "1", "geo": map[string]any{}}},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.MissingRequiredDeep(tt.args)
+			if err != nil {
+				t.Fatalf("MissingRequiredDeep() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingRequiredDeep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_InputPropertyFormats(t *testing.T) {
+	tool := Tool{Tool: mcp.Tool{Name: "schedule", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"start":    map[string]any{"type": "string", "format": "date-time"},
+			"callback": map[string]any{"type": []any{"string", "null"}, "format": "uri"},
+			"title":    map[string]any{"type": "string"},
+			"count":    map[string]any{"type": "integer", "format": "int32"},
+		},
+	}}}
+
+	got, err := tool.InputPropertyFormats()
+	if err != nil {
+		t.Fatalf("InputPropertyFormats() error = %v", err)
+	}
+	want := map[string]string{"start": "date-time", "callback": "uri"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InputPropertyFormats() = %v, want %v", got, want)
+	}
+}
+
+func TestTool_SchemaComplexityWarnings(t *testing.T) {
+	leaf := map[string]any{"type": "string"}
+	deep := map[string]any{"oneOf": []any{
+		map[string]any{"oneOf": []any{
+			map[string]any{"oneOf": []any{leaf, map[string]any{"type": "integer"}}},
+			leaf,
+		}},
+		leaf,
+	}}
+	bigEnum := make([]any, MaxRecommendedEnumValues+1)
+	for i := range bigEnum {
+		bigEnum[i] = i
+	}
+
+	tests := []struct {
+		name   string
+		schema map[string]any
+		want   []string
+	}{
+		{
+			name: "simple",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"q":    leaf,
+					"mode": map[string]any{"anyOf": []any{leaf, map[string]any{"type": "null"}}},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "deep oneOf",
+			schema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"value": deep},
+			},
+			want: []string{"/properties/value/oneOf/0/oneOf/0: combinators nested 3 deep (more than 2)"},
+		},
+		{
+			name: "ref and large enum",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"item": map[string]any{"$ref": "#/$defs/item"},
+					"code": map[string]any{"enum": bigEnum},
+				},
+				"$defs": map[string]any{"item": leaf},
+			},
+			want: []string{
+				`/properties/code: enum has 101 values (more than 100)`,
+				`/properties/item: uses $ref "#/$defs/item"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: tt.schema}}
+			got, err := tool.SchemaComplexityWarnings()
+			if err != nil {
+				t.Fatalf("SchemaComplexityWarnings() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SchemaComplexityWarnings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_DeprecatedInputs(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query":    map[string]any{"type": "string"},
+			"q":        map[string]any{"type": "string", "deprecated": true},
+			"pageSize": map[string]any{"type": "integer", "deprecated": true},
+			"limit":    map[string]any{"type": "integer", "deprecated": false},
+		},
+	}}}
+
+	got, err := tool.DeprecatedInputs()
+	if err != nil {
+		t.Fatalf("DeprecatedInputs() error = %v", err)
+	}
+	if want := []string{"pageSize", "q"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DeprecatedInputs() = %v, want %v", got, want)
+	}
+
+	// Deprecated inputs are still accepted.
+	if err := NewDefaultValidator().ValidateInput(tool, map[string]any{"q": "x", "pageSize": 5}); err != nil {
+		t.Errorf("ValidateInput() with deprecated fields error = %v", err)
+	}
+
+	if _, err := (&Tool{}).DeprecatedInputs(); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("DeprecatedInputs() on nil schema error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestTool_EnumChunks(t *testing.T) {
+	values := make([]any, 2500)
+	for i := range values {
+		values[i] = fmt.Sprintf("v%04d", i)
+	}
+	tool := &Tool{Tool: mcp.Tool{Name: "pick", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"sku":  map[string]any{"type": "string", "enum": values},
+			"note": map[string]any{"type": "string"},
+		},
+	}}}
+
+	chunks, err := tool.EnumChunks("sku", 1000)
+	if err != nil {
+		t.Fatalf("EnumChunks() error = %v", err)
+	}
+	var sizes []int
+	for _, c := range chunks {
+		sizes = append(sizes, len(c))
+	}
+	if want := []int{1000, 1000, 500}; !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("EnumChunks() sizes = %v, want %v", sizes, want)
+	}
+	if chunks[0][0] != "v0000" || chunks[1][0] != "v1000" || chunks[2][499] != "v2499" {
+		t.Errorf("EnumChunks() boundaries = %v, %v, %v", chunks[0][0], chunks[1][0], chunks[2][499])
+	}
+
+	for _, tt := range []struct {
+		property string
+		size     int
+	}{{"note", 10}, {"missing", 10}} {
+		if _, err := tool.EnumChunks(tt.property, tt.size); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("EnumChunks(%q) error = %v, want ErrInvalidSchema", tt.property, err)
+		}
+	}
+	if _, err := tool.EnumChunks("sku", 0); err == nil {
+		t.Error("EnumChunks(size 0) error = nil, want error")
+	}
+}
+
+func TestTool_ArgumentTemplate(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{
+		"type":     "object",
+		"required": []any{"query", "mode", "legacy"},
+		"properties": map[string]any{
+			"query":   map[string]any{"type": "string"},
+			"limit":   map[string]any{"type": "integer", "default": 10},
+			"mode":    map[string]any{"enum": []any{"fast", "exact"}},
+			"version": map[string]any{"const": "v2"},
+			"ratio":   map[string]any{"type": []any{"null", "number"}},
+			"verbose": map[string]any{"type": "boolean"},
+			"filters": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"options": map[string]any{"type": "object"},
+			"extra":   map[string]any{},
+		},
+	}}}
+
+	got, err := tool.ArgumentTemplate()
+	if err != nil {
+		t.Fatalf("ArgumentTemplate() error = %v", err)
+	}
+	want := map[string]any{
+		"query":   "",
+		"limit":   float64(10),
+		"mode":    "fast",
+		"version": "v2",
+		"ratio":   float64(0),
+		"verbose": false,
+		"filters": []any{},
+		"options": map[string]any{},
+		"extra":   nil,
+		"legacy":  nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ArgumentTemplate() = %v, want %v", got, want)
+	}
+}
+
+func TestTool_InputPropertyTitles(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"q":     map[string]any{"type": "string", "title": "Search query"},
+			"limit": map[string]any{"type": "integer", "title": "Max results"},
+			"raw":   map[string]any{"type": "boolean"},
+			"blank": map[string]any{"type": "string", "title": ""},
+		},
+	}}}
+
+	got, err := tool.InputPropertyTitles()
+	if err != nil {
+		t.Fatalf("InputPropertyTitles() error = %v", err)
+	}
+	want := map[string]string{"q": "Search query", "limit": "Max results"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InputPropertyTitles() = %v, want %v", got, want)
+	}
+}
+
+func TestTool_ValidateField(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "create", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "minLength": 3},
+			"owner": map[string]any{"$ref": "#/$defs/user"},
+		},
+		"required": []any{"name", "owner"},
+		"$defs": map[string]any{
+			"user": map[string]any{"type": "string", "pattern": "^@"},
+		},
+	}}}
+
+	tests := []struct {
+		name     string
+		property string
+		value    any
+		wantErr  bool
+		sentinel error
+	}{
+		{"valid", "name", "widget", false, nil},
+		{"too short", "name", "ab", true, nil},
+		{"wrong type", "name", 42, true, nil},
+		{"ref valid", "owner", "@ada", false, nil},
+		{"ref invalid", "owner", "ada", true, nil},
+		{"unknown property", "color", "red", true, ErrInvalidSchema},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.ValidateField(tt.property, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateField(%q, %v) error = %v, wantErr %v", tt.property, tt.value, err, tt.wantErr)
+			}
+			if tt.sentinel != nil && !errors.Is(err, tt.sentinel) {
+				t.Errorf("ValidateField() error = %v, want %v", err, tt.sentinel)
+			}
+			if err != nil && tt.sentinel == nil && errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("ValidateField() error = %v, want a validation failure", err)
+			}
+		})
+	}
+}
+
+func TestTool_ValidateWithUpdate(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "book", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"city": map[string]any{"type": "string"},
+			"trip": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"nights": map[string]any{"type": "integer", "minimum": 1},
+					"guests": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+				"required": []any{"nights", "guests"},
+			},
+		},
+		"required": []any{"city", "trip"},
+	}}}
+	current := map[string]any{"trip": map[string]any{"guests": []any{"ada"}}}
+
+	tests := []struct {
+		name    string
+		args    map[string]any // current when nil
+		pointer string
+		value   any
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "nested field",
+			pointer: "/trip/nights",
+			value:   2,
+			want:    map[string]any{"trip": map[string]any{"guests": []any{"ada"}, "nights": 2}},
+		},
+		{
+			name:    "append to array",
+			pointer: "/trip/guests/-",
+			value:   "grace",
+			want:    map[string]any{"trip": map[string]any{"guests": []any{"ada", "grace"}}},
+		},
+		{
+			name:    "creates intermediate objects",
+			args:    map[string]any{"city": "Paris"},
+			pointer: "/trip/nights",
+			value:   2,
+			want:    map[string]any{"city": "Paris", "trip": map[string]any{"nights": 2}},
+		},
+		{name: "violates schema", pointer: "/trip/nights", value: 0, wantErr: true},
+		{name: "wrong type", pointer: "/trip/guests/0", value: 7, wantErr: true},
+		{name: "index out of range", pointer: "/trip/guests/3", value: "x", wantErr: true},
+		{name: "not a pointer", pointer: "trip", value: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if args == nil {
+				args = current
+			}
+			got, err := tool.ValidateWithUpdate(args, tt.pointer, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWithUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateWithUpdate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if want := (map[string]any{"trip": map[string]any{"guests": []any{"ada"}}}); !reflect.DeepEqual(current, want) {
+		t.Errorf("ValidateWithUpdate() modified current: %v", current)
+	}
+	if got, err := tool.ValidateWithUpdate(nil, "/trip/nights", 1); err != nil || !reflect.DeepEqual(got, map[string]any{"trip": map[string]any{"nights": 1}}) {
+		t.Errorf("ValidateWithUpdate(nil) = %v, %v", got, err)
+	}
+}
+
+func TestTool_ValidateWithUpdate_Combinators(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		args    map[string]any
+		pointer string
+		value   any
+		wantErr bool
+	}{
+		{
+			name: "oneOf of required sets",
+			schema: map[string]any{
+				"type":  "object",
+				"oneOf": []any{map[string]any{"required": []any{"card"}}, map[string]any{"required": []any{"iban"}}},
+			},
+			pointer: "/card",
+			value:   "4242",
+		},
+		{
+			name: "oneOf matched twice",
+			schema: map[string]any{
+				"type":  "object",
+				"oneOf": []any{map[string]any{"required": []any{"card"}}, map[string]any{"required": []any{"iban"}}},
+			},
+			args:    map[string]any{"card": "4242"},
+			pointer: "/iban",
+			value:   "DE00",
+			wantErr: true,
+		},
+		{
+			name:    "not of required pair",
+			schema:  map[string]any{"type": "object", "not": map[string]any{"required": []any{"a", "b"}}},
+			pointer: "/a",
+			value:   1,
+		},
+		{
+			name:    "not of required pair violated",
+			schema:  map[string]any{"type": "object", "not": map[string]any{"required": []any{"a", "b"}}},
+			args:    map[string]any{"a": 1},
+			pointer: "/b",
+			value:   2,
+			wantErr: true,
+		},
+		{
+			name: "if/then required is relaxed",
+			schema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"kind": map[string]any{"type": "string"}, "number": map[string]any{"type": "string"}},
+				"if":         map[string]any{"required": []any{"kind"}, "properties": map[string]any{"kind": map[string]any{"const": "card"}}},
+				"then":       map[string]any{"required": []any{"number"}},
+			},
+			pointer: "/kind",
+			value:   "card",
+		},
+		{
+			name: "if/then constraint still applies",
+			schema: map[string]any{
+				"type": "object",
+				"if":   map[string]any{"required": []any{"kind"}, "properties": map[string]any{"kind": map[string]any{"const": "card"}}},
+				"then": map[string]any{"properties": map[string]any{"number": map[string]any{"type": "string"}}},
+			},
+			args:    map[string]any{"kind": "card"},
+			pointer: "/number",
+			value:   42,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: tt.schema}}
+			_, err := tool.ValidateWithUpdate(tt.args, tt.pointer, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWithUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("validator options apply", func(t *testing.T) {
+		tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"email": map[string]any{"type": "string", "format": "email"}},
+		}}}
+		if _, err := tool.ValidateWithUpdate(nil, "/email", "nope"); err != nil {
+			t.Errorf("ValidateWithUpdate() error = %v", err)
+		}
+		var ve *ValidationError
+		if _, err := tool.ValidateWithUpdate(nil, "/email", "nope", WithFormatAssertion(true)); !errors.As(err, &ve) || ve.Keyword != "format" {
+			t.Errorf("ValidateWithUpdate(WithFormatAssertion) error = %v, want format failure", err)
+		}
+		if _, err := tool.ValidateWithUpdate(nil, "/email", "ada@example.com", WithMaxInstanceBytes(8)); !errors.Is(err, ErrInstanceTooLarge) {
+			t.Errorf("ValidateWithUpdate(WithMaxInstanceBytes) error = %v, want ErrInstanceTooLarge", err)
+		}
+	})
+}
+
+func TestTool_NormalizeSchemaDialect(t *testing.T) {
+	draft07 := json.RawMessage(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"price": {"type": "number", "minimum": 0, "exclusiveMinimum": true, "maximum": 100, "exclusiveMaximum": false},
+			"point": {"type": "array", "items": [{"type": "number"}, {"type": "number"}], "additionalItems": false},
+			"card": {"$ref": "#/definitions/card"}
+		},
+		"dependencies": {"card": ["billing"], "billing": {"required": ["card"]}},
+		"definitions": {"card": {"type": "string", "pattern": "^[0-9]+$"}}
+	}`)
+	tool := &Tool{Tool: mcp.Tool{Name: "pay", InputSchema: draft07}}
+
+	got, err := tool.NormalizeSchemaDialect()
+	if err != nil {
+		t.Fatalf("NormalizeSchemaDialect() error = %v", err)
+	}
+	if string(tool.InputSchema.(json.RawMessage)) != string(draft07) {
+		t.Error("NormalizeSchemaDialect() modified the original tool")
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"price": map[string]any{"type": "number", "exclusiveMinimum": float64(0), "maximum": float64(100)},
+			"point": map[string]any{"type": "array", "prefixItems": []any{map[string]any{"type": "number"}, map[string]any{"type": "number"}}, "items": false},
+			"card":  map[string]any{"$ref": "#/$defs/card"},
+		},
+		"dependentRequired": map[string]any{"card": []any{"billing"}},
+		"dependentSchemas":  map[string]any{"billing": map[string]any{"required": []any{"card"}}},
+		"$defs":             map[string]any{"card": map[string]any{"type": "string", "pattern": "^[0-9]+$"}},
+	}
+	if !reflect.DeepEqual(got.InputSchema, want) {
+		t.Errorf("InputSchema = %v, want %v", got.InputSchema, want)
+	}
+
+	v := NewDefaultValidator()
+	instances := []struct {
+		args  map[string]any
+		valid bool
+	}{
+		{map[string]any{"price": 0.5}, true},
+		{map[string]any{"price": 0}, false},
+		{map[string]any{"price": 100}, true},
+		{map[string]any{"point": []any{1, 2}}, true},
+		{map[string]any{"point": []any{1, 2, 3}}, false},
+		{map[string]any{"card": "4242", "billing": "x"}, true},
+		{map[string]any{"card": "4242"}, false},
+		{map[string]any{"card": "abc", "billing": "x"}, false},
+	}
+	for _, tt := range instances {
+		if err := v.ValidateInput(got, tt.args); (err == nil) != tt.valid {
+			t.Errorf("ValidateInput(%v) error = %v, want valid %v", tt.args, err, tt.valid)
+		}
+	}
+
+	if _, err := (&Tool{Tool: mcp.Tool{Name: "t", InputSchema: map[string]any{"$schema": "http://json-schema.org/draft-04/schema#"}}}).NormalizeSchemaDialect(); !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("NormalizeSchemaDialect(draft-04) error = %v, want ErrUnsupportedSchema", err)
+	}
+
+	clash := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: map[string]any{
+		"properties":  map[string]any{"id": map[string]any{"$ref": "#/definitions/id"}},
+		"definitions": map[string]any{"id": map[string]any{"type": "integer"}, "name": map[string]any{"type": "string"}},
+		"$defs":       map[string]any{"id": map[string]any{"type": "string"}, "name": map[string]any{"type": "string"}},
+	}}}
+	if _, err := clash.NormalizeSchemaDialect(); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("NormalizeSchemaDialect(definitions/$defs clash) error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestTool_ParamSchema(t *testing.T) {
+	raw := `{"type":"object","properties":{"q":{"type":"string","minLength":1},"any":true,"none":false}}`
+	for _, schema := range []any{
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"q":    map[string]any{"type": "string", "minLength": 1},
+				"any":  true,
+				"none": false,
+			},
+		},
+		json.RawMessage(raw),
+		[]byte(raw),
+	} {
+		t.Run(fmt.Sprintf("%T", schema), func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema}}
+			tests := []struct {
+				name      string
+				want      map[string]any
+				wantFound bool
+			}{
+				{"q", map[string]any{"type": "string", "minLength": float64(1)}, true},
+				{"any", map[string]any{}, true},
+				{"none", map[string]any{"not": map[string]any{}}, true},
+				{"missing", nil, false},
+			}
+			for _, tt := range tests {
+				got, found, err := tool.ParamSchema(tt.name)
+				if err != nil {
+					t.Fatalf("ParamSchema(%q) error = %v", tt.name, err)
+				}
+				if found != tt.wantFound || !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ParamSchema(%q) = %v, %v, want %v, %v", tt.name, got, found, tt.want, tt.wantFound)
+				}
+			}
+		})
+	}
+
+	malformed := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: json.RawMessage(`{"type":`)}}
+	if _, _, err := malformed.ParamSchema("q"); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ParamSchema() error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestTool_PropertyConstraints(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "create", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"slug": map[string]any{
+				"type":        "string",
+				"title":       "Slug",
+				"minLength":   3,
+				"pattern":     "^[a-z-]+$",
+				"description": "URL-safe name",
+			},
+			"ratio": map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+			"any":   true,
+		},
+	}}}
+
+	tests := []struct {
+		property string
+		want     map[string]any
+	}{
+		{"slug", map[string]any{"minLength": float64(3), "pattern": "^[a-z-]+$"}},
+		{"ratio", map[string]any{"minimum": float64(0), "maximum": float64(1)}},
+		{"any", map[string]any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			got, err := tool.PropertyConstraints(tt.property)
+			if err != nil {
+				t.Fatalf("PropertyConstraints(%q) error = %v", tt.property, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PropertyConstraints(%q) = %v, want %v", tt.property, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := tool.PropertyConstraints("color"); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("PropertyConstraints(undeclared) error = %v, want ErrInvalidSchema", err)
+	}
+}
@@ -3,9 +3,13 @@ package toolmodel
 import (
 	"encoding/json"
 	"errors"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -284,6 +288,26 @@ func TestToolBackend_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid MCP backend with auth scopes",
+			backend: ToolBackend{
+				Kind: BackendKindMCP,
+				MCP:  &MCPBackend{ServerName: "server", AuthScopes: []string{"repo:read"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid Provider backend with empty auth scope",
+			backend: ToolBackend{
+				Kind: BackendKindProvider,
+				Provider: &ProviderBackend{
+					ProviderID: "provider",
+					ToolID:     "tool",
+					AuthScopes: []string{"read", " "},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "unknown backend kind",
 			backend: ToolBackend{
@@ -475,6 +499,59 @@ func TestFromMCPJSON_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestTool_MCPJSONWithBackends(t *testing.T) {
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Meta:        mcp.Meta{"trace": "abc"},
+			InputSchema: map[string]any{"type": "object"},
+		},
+		Namespace: "docs",
+		Backends: []ToolBackend{{
+			Kind: BackendKindMCP,
+			MCP:  &MCPBackend{ServerName: "docs-server", AuthScopes: []string{"docs:read"}},
+		}},
+	}
+
+	data, err := tool.ToMCPJSONWithBackends()
+	if err != nil {
+		t.Fatalf("ToMCPJSONWithBackends() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"`+MetaKeyBackends+`":[{"kind":"mcp"`) {
+		t.Errorf("ToMCPJSONWithBackends() = %s, want backends in _meta", data)
+	}
+	if len(tool.Meta) != 1 {
+		t.Errorf("ToMCPJSONWithBackends() modified Meta: %v", tool.Meta)
+	}
+	plain, err := tool.ToMCPJSON()
+	if err != nil {
+		t.Fatalf("ToMCPJSON() error = %v", err)
+	}
+	if strings.Contains(string(plain), "backends") {
+		t.Errorf("ToMCPJSON() = %s, want no backends", plain)
+	}
+
+	got, err := FromMCPJSONWithBackends(data)
+	if err != nil {
+		t.Fatalf("FromMCPJSONWithBackends() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Backends, tool.Backends) {
+		t.Errorf("Backends = %+v, want %+v", got.Backends, tool.Backends)
+	}
+	if want := (mcp.Meta{"trace": "abc"}); !reflect.DeepEqual(got.Meta, want) {
+		t.Errorf("Meta = %v, want %v", got.Meta, want)
+	}
+
+	got, err = FromMCPJSONWithBackends(plain)
+	if err != nil || got.Backends != nil {
+		t.Errorf("FromMCPJSONWithBackends(plain) = %+v, %v, want no backends", got, err)
+	}
+	_, err = FromMCPJSONWithBackends([]byte(`{"name":"t","inputSchema":{},"_meta":{"` + MetaKeyBackends + `":"mcp"}}`))
+	if !errors.Is(err, ErrInvalidBackend) {
+		t.Errorf("FromMCPJSONWithBackends(malformed) error = %v, want ErrInvalidBackend", err)
+	}
+}
+
 func TestFromJSON(t *testing.T) {
 	toolJSON := `{
 		"name": "full-tool",
@@ -793,3 +870,1141 @@ func TestNormalizeTags(t *testing.T) {
 		})
 	}
 }
+
+func TestTool_HasStructuredOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		want   bool
+	}{
+		{name: "nil", schema: nil, want: false},
+		{name: "nil map", schema: map[string]any(nil), want: false},
+		{name: "map", schema: map[string]any{"type": "object"}, want: true},
+		{name: "empty map", schema: map[string]any{}, want: true},
+		{name: "raw message", schema: json.RawMessage(`{"type":"object"}`), want: true},
+		{name: "empty raw message", schema: json.RawMessage{}, want: false},
+		{name: "nil raw message", schema: json.RawMessage(nil), want: false},
+		{name: "null raw message", schema: json.RawMessage(" null "), want: false},
+		{name: "bytes", schema: []byte(`{"type":"object"}`), want: true},
+		{name: "empty bytes", schema: []byte{}, want: false},
+		{name: "jsonschema schema", schema: &jsonschema.Schema{Type: "object"}, want: true},
+		{name: "nil jsonschema schema", schema: (*jsonschema.Schema)(nil), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := Tool{Tool: mcp.Tool{Name: "t", OutputSchema: tt.schema}}
+			if got := tool.HasStructuredOutput(); got != tt.want {
+				t.Errorf("HasStructuredOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHierarchicalName_RoundTrip(t *testing.T) {
+	path := SplitHierarchicalName("a.b.c")
+	want := []string{"a", "b", "c"}
+	if len(path) != len(want) {
+		t.Fatalf("SplitHierarchicalName() = %v, want %v", path, want)
+	}
+	for i := range want {
+		if path[i] != want[i] {
+			t.Fatalf("SplitHierarchicalName()[%d] = %q, want %q", i, path[i], want[i])
+		}
+	}
+	if got := JoinHierarchicalName(path...); got != "a.b.c" {
+		t.Errorf("JoinHierarchicalName() = %q, want %q", got, "a.b.c")
+	}
+	if got := SplitHierarchicalName("a..b."); len(got) != 2 {
+		t.Errorf("SplitHierarchicalName() = %v, want empty segments dropped", got)
+	}
+	if got := JoinHierarchicalName("a", "", "b"); got != "a.b" {
+		t.Errorf("JoinHierarchicalName() = %q, want %q", got, "a.b")
+	}
+}
+
+func TestTool_NormalizeHierarchicalName(t *testing.T) {
+	tests := []struct {
+		name          string
+		tool          Tool
+		wantNamespace string
+		wantName      string
+	}{
+		{
+			name:          "dotted name without namespace",
+			tool:          Tool{Tool: mcp.Tool{Name: "team.service.tool"}},
+			wantNamespace: "team.service",
+			wantName:      "tool",
+		},
+		{
+			name:          "dotted name with namespace",
+			tool:          Tool{Tool: mcp.Tool{Name: "service.tool"}, Namespace: "team"},
+			wantNamespace: "team.service",
+			wantName:      "tool",
+		},
+		{
+			name:          "plain name",
+			tool:          Tool{Tool: mcp.Tool{Name: "tool"}, Namespace: "team"},
+			wantNamespace: "team",
+			wantName:      "tool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.tool.NormalizeHierarchicalName()
+			if tt.tool.Namespace != tt.wantNamespace || tt.tool.Name != tt.wantName {
+				t.Errorf("NormalizeHierarchicalName() = (%q, %q), want (%q, %q)",
+					tt.tool.Namespace, tt.tool.Name, tt.wantNamespace, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestTool_SortKey(t *testing.T) {
+	tool := func(namespace, name, version string) Tool {
+		return Tool{Tool: mcp.Tool{Name: name}, Namespace: namespace, Version: version}
+	}
+	want := []Tool{
+		tool("", "zeta", ""),
+		tool("alpha", "read", "1.2.0"),
+		tool("alpha", "read", "1.10.0-rc.1"),
+		tool("alpha", "read", "1.10.0"),
+		tool("alpha", "read", "v2.0.0"),
+		tool("alpha", "write", "0.1.0"),
+		tool("beta", "read", "latest"),
+		tool("beta", "read", "nightly"),
+	}
+
+	got := make([]Tool, len(want))
+	for i := range want {
+		got[i] = want[len(want)-1-i]
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].SortKey() < got[j].SortKey() })
+
+	for i := range want {
+		if got[i].ToolID() != want[i].ToolID() || got[i].Version != want[i].Version {
+			t.Errorf("position %d = %s@%s, want %s@%s", i, got[i].ToolID(), got[i].Version, want[i].ToolID(), want[i].Version)
+		}
+	}
+}
+
+func TestTool_CostAndRateLimitHints(t *testing.T) {
+	base := mcp.Tool{Name: "plan", InputSchema: map[string]any{"type": "object"}}
+
+	for _, tool := range []Tool{
+		{Tool: base, CostHint: -1},
+		{Tool: base, RateLimitPerMinute: -5},
+	} {
+		if err := tool.Validate(); !errors.Is(err, ErrInvalidTool) {
+			t.Errorf("Validate() error = %v, want ErrInvalidTool", err)
+		}
+	}
+
+	tool := Tool{Tool: base, CostHint: 3, RateLimitPerMinute: 60}
+	if err := tool.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if got := tool.CostHintOr(1); got != 3 {
+		t.Errorf("CostHintOr() = %d, want 3", got)
+	}
+	if got := (&Tool{}).RateLimitPerMinuteOr(10); got != 10 {
+		t.Errorf("RateLimitPerMinuteOr() = %d, want default 10", got)
+	}
+
+	data, err := tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	restored, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+	if restored.CostHint != 3 || restored.RateLimitPerMinute != 60 {
+		t.Errorf("round-trip hints = (%d, %d), want (3, 60)", restored.CostHint, restored.RateLimitPerMinute)
+	}
+
+	mcpData, err := tool.ToMCPJSON()
+	if err != nil {
+		t.Fatalf("ToMCPJSON() error = %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(mcpData, &result); err != nil {
+		t.Fatalf("Failed to unmarshal ToMCPJSON result: %v", err)
+	}
+	for _, key := range []string{"costHint", "rateLimitPerMinute"} {
+		if _, ok := result[key]; ok {
+			t.Errorf("ToMCPJSON() should not include %s", key)
+		}
+	}
+}
+
+func TestTool_HiddenJSON(t *testing.T) {
+	tool := Tool{
+		Tool:   mcp.Tool{Name: "internal", InputSchema: map[string]any{"type": "object"}},
+		Hidden: true,
+	}
+
+	data, err := tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	restored, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+	if !restored.Hidden {
+		t.Error("round-trip Hidden = false, want true")
+	}
+
+	mcpData, err := tool.ToMCPJSON()
+	if err != nil {
+		t.Fatalf("ToMCPJSON() error = %v", err)
+	}
+	if strings.Contains(string(mcpData), "hidden") {
+		t.Errorf("ToMCPJSON() = %s, should not include hidden", mcpData)
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{name: "short string unchanged", in: "Search docs", max: 20, want: "Search docs"},
+		{name: "exact fit unchanged", in: "abc", max: 3, want: "abc"},
+		{name: "ascii truncated", in: "Search documents", max: 7, want: "Search…"},
+		{name: "multibyte truncated on rune boundary", in: "日本語のテキスト", max: 4, want: "日本語…"},
+		{name: "emoji", in: "🔍🔍🔍🔍", max: 2, want: "🔍…"},
+		{name: "max one", in: "abc", max: 1, want: "…"},
+		{name: "zero max", in: "abc", max: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateDescription(tt.in, tt.max)
+			if got != tt.want {
+				t.Errorf("TruncateDescription(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TruncateDescription(%q, %d) produced invalid UTF-8", tt.in, tt.max)
+			}
+			if n := utf8.RuneCountInString(got); tt.max > 0 && n > tt.max {
+				t.Errorf("TruncateDescription(%q, %d) has %d runes, want <= %d", tt.in, tt.max, n, tt.max)
+			}
+		})
+	}
+}
+
+func TestTool_ToJSONProjection(t *testing.T) {
+	tool := Tool{
+		Tool: mcp.Tool{
+			Name:         "search",
+			Description:  "Search docs",
+			InputSchema:  map[string]any{"type": "object"},
+			OutputSchema: map[string]any{"type": "object"},
+		},
+		Namespace: "docs",
+		Tags:      []string{"search"},
+	}
+
+	data, err := tool.ToJSONProjection("name", "namespace", "tags", "nonexistent")
+	if err != nil {
+		t.Fatalf("ToJSONProjection() error = %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to unmarshal projection: %v", err)
+	}
+	if len(result) != 3 || result["name"] != "search" || result["namespace"] != "docs" || result["tags"] == nil {
+		t.Errorf("ToJSONProjection() = %s, want only name, namespace, tags", data)
+	}
+
+	data, err = tool.ToSummaryJSON()
+	if err != nil {
+		t.Fatalf("ToSummaryJSON() error = %v", err)
+	}
+	result = nil
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to unmarshal summary: %v", err)
+	}
+	if _, ok := result["inputSchema"]; ok {
+		t.Error("ToSummaryJSON() should omit inputSchema")
+	}
+	if _, ok := result["outputSchema"]; ok {
+		t.Error("ToSummaryJSON() should omit outputSchema")
+	}
+	if result["name"] != "search" || result["description"] != "Search docs" || result["namespace"] != "docs" {
+		t.Errorf("ToSummaryJSON() = %s, want name, description, and namespace", data)
+	}
+}
+
+func TestToolValidateWithOptions_AllowedNamespaces(t *testing.T) {
+	tool := func(namespace string) Tool {
+		return Tool{
+			Tool:      mcp.Tool{Name: "read", InputSchema: map[string]any{"type": "object"}},
+			Namespace: namespace,
+		}
+	}
+	allow := []string{"tenant-a", "shared"}
+
+	tests := []struct {
+		name    string
+		tool    Tool
+		opts    ValidateOptions
+		wantErr bool
+	}{
+		{name: "on-list namespace", tool: tool("tenant-a"), opts: ValidateOptions{AllowedNamespaces: allow}},
+		{name: "off-list namespace", tool: tool("tenant-b"), opts: ValidateOptions{AllowedNamespaces: allow}, wantErr: true},
+		{name: "empty namespace denied", tool: tool(""), opts: ValidateOptions{AllowedNamespaces: allow}, wantErr: true},
+		{
+			name: "empty namespace allowed",
+			tool: tool(""),
+			opts: ValidateOptions{AllowedNamespaces: allow, AllowEmptyNamespace: true},
+		},
+		{name: "no allowlist", tool: tool("anything")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tool.ValidateWithOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidTool) {
+				t.Errorf("ValidateWithOptions() error = %v, want ErrInvalidTool", err)
+			}
+		})
+	}
+}
+
+func TestTool_CategoryJSON(t *testing.T) {
+	tool := Tool{
+		Tool:     mcp.Tool{Name: "create", InputSchema: map[string]any{"type": "object"}},
+		Category: "Productivity",
+	}
+
+	data, err := tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	restored, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+	if restored.Category != "Productivity" {
+		t.Errorf("round-trip Category = %q, want %q", restored.Category, "Productivity")
+	}
+
+	mcpData, err := tool.ToMCPJSON()
+	if err != nil {
+		t.Fatalf("ToMCPJSON() error = %v", err)
+	}
+	if strings.Contains(string(mcpData), "category") {
+		t.Errorf("ToMCPJSON() = %s, should not include category", mcpData)
+	}
+}
+
+func TestTool_DeterministicJSON(t *testing.T) {
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "compare",
+			Description: "Checks a < b && b > c",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"b": map[string]any{"type": "number", "maximum": 10.0, "multipleOf": 0.5},
+					"a": map[string]any{"type": "string", "pattern": "^<tag>$"},
+				},
+			},
+		},
+		Namespace: "math",
+		Tags:      []string{"cmp"},
+	}
+	want := `{"description":"Checks a < b && b > c",` +
+		`"inputSchema":{"properties":{"a":{"pattern":"^<tag>$","type":"string"},` +
+		`"b":{"maximum":10,"multipleOf":0.5,"type":"number"}},"type":"object"},` +
+		`"name":"compare","namespace":"math","tags":["cmp"]}`
+
+	got, err := tool.DeterministicJSON()
+	if err != nil {
+		t.Fatalf("DeterministicJSON() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("DeterministicJSON() =\n%s\nwant\n%s", got, want)
+	}
+
+	raw := tool.Clone()
+	raw.InputSchema = json.RawMessage(`{"type":"object","properties":{"a":{"type":"string","pattern":"^<tag>$"},"b":{"multipleOf":0.5,"type":"number","maximum":10}}}`)
+	again, err := raw.DeterministicJSON()
+	if err != nil {
+		t.Fatalf("DeterministicJSON() error = %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("DeterministicJSON() depends on representation:\n%s\n%s", again, got)
+	}
+
+	big := tool.Clone()
+	big.InputSchema = json.RawMessage(`{"type":"object","properties":{"id":{"type":"integer","minimum":9007199254740993,"maximum":18446744073709551615}}}`)
+	out, err := big.DeterministicJSON()
+	if err != nil {
+		t.Fatalf("DeterministicJSON() error = %v", err)
+	}
+	for _, want := range []string{`"minimum":9007199254740993`, `"maximum":18446744073709551615`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("DeterministicJSON() = %s, want %s preserved", out, want)
+		}
+	}
+}
+
+func TestTool_Fingerprint(t *testing.T) {
+	a := Tool{Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"limit": map[string]any{"type": "integer", "default": 10}},
+	}}}
+	b := Tool{Tool: mcp.Tool{Name: "search", InputSchema: json.RawMessage(
+		`{"properties":{"limit":{"default":10.0,"type":"integer"}},"type":"object"}`,
+	)}}
+
+	fa, err := a.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	fb, err := b.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if fa != fb {
+		t.Errorf("Fingerprint() differs for equivalent schemas: %s vs %s", fa, fb)
+	}
+	if len(fa) != 64 {
+		t.Errorf("Fingerprint() = %q, want 64 hex chars", fa)
+	}
+
+	b.Version = "2.0.0"
+	if fb2, _ := b.Fingerprint(); fb2 == fa {
+		t.Error("Fingerprint() unchanged after changing Version")
+	}
+
+	withMax := func(max string) *Tool {
+		c := a.Clone()
+		c.InputSchema = json.RawMessage(`{"type":"object","properties":{"n":{"type":"integer","maximum":` + max + `}}}`)
+		return c
+	}
+	exact, err := withMax("9007199254740993").Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	rounded, err := withMax("9007199254740992").Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if exact == rounded {
+		t.Error("Fingerprint() equal for integers differing beyond float64 precision")
+	}
+	if _, err := withMax("1e400").Fingerprint(); err != nil {
+		t.Errorf("Fingerprint() with 1e400 error = %v", err)
+	}
+	if _, err := withMax("1e400").DeterministicJSON(); err != nil {
+		t.Errorf("DeterministicJSON() with 1e400 error = %v", err)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []string
+	}{
+		{"sorted union", []string{"b", "a"}, []string{"a", "c"}, []string{"a", "b", "c"}},
+		{"swapped arguments", []string{"a", "c"}, []string{"b", "a"}, []string{"a", "b", "c"}},
+		{"normalizes", []string{" Web Search "}, []string{"web-search", "API"}, []string{"api", "web-search"}},
+		{"empty", nil, nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeTags(tt.a, tt.b)
+			if len(got) != len(tt.want) {
+				t.Fatalf("MergeTags() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("MergeTags() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalizeToolID(t *testing.T) {
+	for _, id := range []string{"docs:search", "Docs:Search", " docs:search ", "DOCS : search"} {
+		got, err := CanonicalizeToolID(id)
+		if err != nil {
+			t.Errorf("CanonicalizeToolID(%q) error = %v", id, err)
+			continue
+		}
+		if got != "docs:search" {
+			t.Errorf("CanonicalizeToolID(%q) = %q, want docs:search", id, got)
+		}
+	}
+
+	if got, err := CanonicalizeToolID(" Echo "); err != nil || got != "echo" {
+		t.Errorf("CanonicalizeToolID(\" Echo \") = %q, %v, want echo", got, err)
+	}
+
+	for _, id := range []string{"", "   ", "a:b:c", ":search", "docs:", "docs: ", "my docs:search", "docs:sea/rch"} {
+		if _, err := CanonicalizeToolID(id); !errors.Is(err, ErrInvalidToolID) {
+			t.Errorf("CanonicalizeToolID(%q) error = %v, want ErrInvalidToolID", id, err)
+		}
+	}
+}
+
+func TestToolIDsEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Docs:Search", "docs:search", true},
+		{" docs:search", "DOCS : SEARCH ", true},
+		{"Echo", "echo", true},
+		{"docs:search", "docs:fetch", false},
+		{"docs:search", "web:search", false},
+		{"search", "docs:search", false},
+		{"a:b:c", "a:b:c", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := ToolIDsEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("ToolIDsEqual(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTool_DisplayName(t *testing.T) {
+	tests := []struct {
+		name string
+		tool Tool
+		want string
+	}{
+		{"title", Tool{Tool: mcp.Tool{Name: "search", Title: "Search", Annotations: &mcp.ToolAnnotations{Title: "Find"}}}, "Search"},
+		{"annotation title", Tool{Tool: mcp.Tool{Name: "search", Annotations: &mcp.ToolAnnotations{Title: "Find"}}}, "Find"},
+		{"name", Tool{Tool: mcp.Tool{Name: "search", Annotations: &mcp.ToolAnnotations{}}}, "search"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tool.DisplayName(); got != tt.want {
+				t.Errorf("DisplayName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_FunctionallyEqual(t *testing.T) {
+	base := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Description: "Search documents",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"limit": map[string]any{"type": "integer", "default": 10}},
+			},
+		},
+		Namespace: "docs",
+		Version:   "1.0.0",
+		Tags:      []string{"search"},
+	}
+	sameTool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Description: "Search documents",
+			InputSchema: json.RawMessage(`{"properties":{"limit":{"default":10.0,"type":"integer"}},"type":"object"}`),
+		},
+		Namespace: "kb",
+		Version:   "2.0.0",
+		Tags:      []string{"lookup", "docs"},
+	}
+	otherSchema := &Tool{Tool: mcp.Tool{
+		Name:        "search",
+		Description: "Search documents",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"limit": map[string]any{"type": "string"}},
+		},
+	}}
+	withOutput := &Tool{Tool: base.Tool}
+	withOutput.OutputSchema = map[string]any{"type": "object"}
+
+	tests := []struct {
+		name  string
+		other *Tool
+		want  bool
+	}{
+		{"differs only in metadata", sameTool, true},
+		{"different input schema", otherSchema, false},
+		{"extra output schema", withOutput, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.FunctionallyEqual(tt.other); got != tt.want {
+				t.Errorf("FunctionallyEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_Equal(t *testing.T) {
+	base := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Description: "Search documents",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"q": map[string]any{"type": "string"}, "limit": map[string]any{"type": "integer"}},
+			},
+		},
+		Namespace: "docs",
+		Version:   "1.0.0",
+		Tags:      []string{"search", "Docs"},
+	}
+	variant := func(edit func(*Tool)) *Tool {
+		c := base.Clone()
+		edit(c)
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		other *Tool
+		want  bool
+	}{
+		{"clone", base.Clone(), true},
+		{"raw schema in other key order", variant(func(c *Tool) {
+			c.InputSchema = json.RawMessage(`{"properties":{"limit":{"type":"integer"},"q":{"type":"string"}},"type":"object"}`)
+		}), true},
+		{"tags reordered and duplicated", variant(func(c *Tool) { c.Tags = []string{"docs", "search", "DOCS"} }), true},
+		{"different tags", variant(func(c *Tool) { c.Tags = []string{"search"} }), false},
+		{"different namespace", variant(func(c *Tool) { c.Namespace = "kb" }), false},
+		{"different version", variant(func(c *Tool) { c.Version = "2.0.0" }), false},
+		{"different description", variant(func(c *Tool) { c.Description = "Find documents" }), false},
+		{"different schema", variant(func(c *Tool) { c.InputSchema = map[string]any{"type": "object"} }), false},
+		{"extra output schema", variant(func(c *Tool) { c.OutputSchema = map[string]any{"type": "object"} }), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Equal(tt.other); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	noSchemas := &Tool{Tool: mcp.Tool{Name: "t"}}
+	if !noSchemas.Equal(&Tool{Tool: mcp.Tool{Name: "t", OutputSchema: json.RawMessage("null")}}) {
+		t.Error("Equal() = false for tools without schemas")
+	}
+	if !(*Tool)(nil).Equal(nil) {
+		t.Error("nil.Equal(nil) = false, want true")
+	}
+}
+
+func TestTool_RequiredScopes(t *testing.T) {
+	tool := Tool{
+		Tool: mcp.Tool{Name: "sync", InputSchema: map[string]any{"type": "object"}},
+		Backends: []ToolBackend{
+			{Kind: BackendKindMCP, MCP: &MCPBackend{ServerName: "github", AuthScopes: []string{"repo:write", "repo:read"}}},
+			{Kind: BackendKindProvider, Provider: &ProviderBackend{ProviderID: "gh", ToolID: "sync", AuthScopes: []string{"repo:read", "org:read"}}},
+			{Kind: BackendKindLocal, Local: &LocalBackend{Name: "fallback"}},
+		},
+	}
+
+	want := []string{"org:read", "repo:read", "repo:write"}
+	if got := tool.RequiredScopes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredScopes() = %v, want %v", got, want)
+	}
+
+	data, err := tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	decoded, err := FromJSON(data)
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+	if got := decoded.RequiredScopes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredScopes() after round trip = %v, want %v", got, want)
+	}
+	if !strings.Contains(string(data), `"authScopes":["repo:write","repo:read"]`) {
+		t.Errorf("ToJSON() = %s, want authScopes", data)
+	}
+
+	if got := (&Tool{}).RequiredScopes(); got != nil {
+		t.Errorf("RequiredScopes() without backends = %v, want nil", got)
+	}
+}
+
+func TestTool_IconSources(t *testing.T) {
+	tool := Tool{Tool: mcp.Tool{Name: "search", Icons: []mcp.Icon{
+		{Source: "https://example.com/search-16.png", Sizes: []string{"16x16"}},
+		{Source: ""},
+		{Source: "https://example.com/search-dark.svg", Theme: "dark"},
+	}}}
+	want := []string{"https://example.com/search-16.png", "https://example.com/search-dark.svg"}
+	if got := tool.IconSources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IconSources() = %v, want %v", got, want)
+	}
+	if got := (&Tool{}).IconSources(); got != nil {
+		t.Errorf("IconSources() without icons = %v, want nil", got)
+	}
+}
+
+func TestToolValidateWithOptions_RejectDuplicateTags(t *testing.T) {
+	tool := func(tags ...string) *Tool {
+		return &Tool{
+			Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{"type": "object"}},
+			Tags: tags,
+		}
+	}
+	strict := ValidateOptions{RejectDuplicateTags: true}
+
+	tests := []struct {
+		name    string
+		tool    *Tool
+		opts    ValidateOptions
+		wantErr string
+	}{
+		{name: "clean tags", tool: tool("search", "web"), opts: strict},
+		{name: "case duplicates", tool: tool("Foo", "foo"), opts: strict, wantErr: `tags "Foo", "foo" collapse to "foo"`},
+		{name: "invalid tag", tool: tool("ok", "!!!"), opts: strict, wantErr: `tag "!!!" is empty after normalization`},
+		{name: "duplicates allowed by default", tool: tool("Foo", "foo")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tool.ValidateWithOptions(tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateWithOptions() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidTool) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateWithOptions() error = %v, want ErrInvalidTool mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToolValidateWithOptions_LowercaseNamesOnly(t *testing.T) {
+	tool := func(name string) *Tool {
+		return &Tool{Tool: mcp.Tool{Name: name, InputSchema: map[string]any{"type": "object"}}}
+	}
+	strict := ValidateOptions{LowercaseNamesOnly: true}
+
+	tests := []struct {
+		name    string
+		tool    *Tool
+		opts    ValidateOptions
+		wantErr bool
+	}{
+		{name: "lowercase", tool: tool("search_docs-v2.1"), opts: strict},
+		{name: "uppercase", tool: tool("searchDocs"), opts: strict, wantErr: true},
+		{name: "mixed case allowed by default", tool: tool("searchDocs")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tool.ValidateWithOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTool) {
+				t.Errorf("ValidateWithOptions() error = %v, want ErrInvalidTool", err)
+			}
+		})
+	}
+}
+
+func TestTool_ValidateAgainstReserved(t *testing.T) {
+	reserved := []string{"help", "list_tools", "cancel"}
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"help", true},
+		{"HELP", true},
+		{"List_Tools", true},
+		{"helper", false},
+		{"search", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: tt.name, InputSchema: map[string]any{"type": "object"}}}
+			err := tool.ValidateAgainstReserved(reserved)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAgainstReserved() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTool) {
+				t.Errorf("ValidateAgainstReserved() error = %v, want ErrInvalidTool", err)
+			}
+		})
+	}
+
+	if err := (&Tool{Tool: mcp.Tool{Name: "help"}}).ValidateAgainstReserved(nil); err != nil {
+		t.Errorf("ValidateAgainstReserved(nil) error = %v", err)
+	}
+}
+
+func TestToolValidateWithOptions_RequireObjectOutput(t *testing.T) {
+	tool := func(output any) *Tool {
+		return &Tool{Tool: mcp.Tool{Name: "t", InputSchema: map[string]any{"type": "object"}, OutputSchema: output}}
+	}
+	strict := ValidateOptions{RequireObjectOutput: true}
+
+	tests := []struct {
+		name    string
+		tool    *Tool
+		opts    ValidateOptions
+		wantErr bool
+	}{
+		{name: "object", tool: tool(map[string]any{"type": "object"}), opts: strict},
+		{name: "raw object", tool: tool(json.RawMessage(`{"type":"object"}`)), opts: strict},
+		{name: "no output schema", tool: tool(nil), opts: strict},
+		{name: "array", tool: tool(map[string]any{"type": "array"}), opts: strict, wantErr: true},
+		{name: "scalar", tool: tool(map[string]any{"type": "string"}), opts: strict, wantErr: true},
+		{name: "missing type", tool: tool(map[string]any{"properties": map[string]any{}}), opts: strict, wantErr: true},
+		{name: "boolean", tool: tool(json.RawMessage(`true`)), opts: strict, wantErr: true},
+		{name: "array allowed by default", tool: tool(map[string]any{"type": "array"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tool.ValidateWithOptions(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTool) {
+				t.Errorf("ValidateWithOptions() error = %v, want ErrInvalidTool", err)
+			}
+		})
+	}
+}
+
+func TestTool_String(t *testing.T) {
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Description: "Search documents",
+			InputSchema: json.RawMessage(`{"type":"object","required":["q"],"properties":{"q":{"type":"string"},"limit":{"type":"integer","default":10.0}}}`),
+		},
+		Namespace: "docs",
+		Version:   "1.2.0",
+		Tags:      []string{"search", "docs"},
+	}
+
+	const golden = `tool docs:search
+version: 1.2.0
+tags: docs, search
+description: Search documents
+inputSchema:
+  {
+    "properties": {
+      "limit": {
+        "default": 10,
+        "type": "integer"
+      },
+      "q": {
+        "type": "string"
+      }
+    },
+    "required": [
+      "q"
+    ],
+    "type": "object"
+  }
+`
+	if got := tool.String(); got != golden {
+		t.Errorf("String() =\n%s\nwant\n%s", got, golden)
+	}
+	if tool.Tags[0] != "search" {
+		t.Error("String() reordered the tool's tags")
+	}
+}
+
+func TestTool_ValidateID(t *testing.T) {
+	tests := []struct {
+		namespace, name string
+		wantErr         bool
+	}{
+		{"a.b", "c", false},
+		{"a.b", "c.d", false},
+		{"", "team.service.tool", false},
+		{"docs", "search", false},
+		{"a:b", "c", true},
+		{"docs", "sea:rch", true},
+		{"", "ns:name", true},
+		{"docs", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace+"|"+tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: tt.name}, Namespace: tt.namespace}
+			err := tool.ValidateID()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidToolID) {
+				t.Errorf("ValidateID() error = %v, want ErrInvalidToolID", err)
+			}
+		})
+	}
+}
+
+func TestTool_SafeForAutoInvoke(t *testing.T) {
+	destructive := true
+	tests := []struct {
+		name        string
+		annotations *mcp.ToolAnnotations
+		want        bool
+	}{
+		{"read-only idempotent", &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true}, true},
+		{"read-only only", &mcp.ToolAnnotations{ReadOnlyHint: true}, false},
+		{"idempotent only", &mcp.ToolAnnotations{IdempotentHint: true}, false},
+		{"destructive", &mcp.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true, DestructiveHint: &destructive}, false},
+		{"no annotations", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", Annotations: tt.annotations}}
+			if got := tool.SafeForAutoInvoke(); got != tt.want {
+				t.Errorf("SafeForAutoInvoke() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_Localized(t *testing.T) {
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Title:       "Search",
+			Description: "Search documents",
+			InputSchema: map[string]any{"type": "object"},
+		},
+		Localizations: map[string]ToolL10n{
+			"fr":    {Title: "Rechercher", Description: "Rechercher des documents"},
+			"pt-BR": {Description: "Pesquisar documentos"},
+		},
+	}
+
+	tests := []struct {
+		lang      string
+		wantTitle string
+		wantDesc  string
+	}{
+		{"fr", "Rechercher", "Rechercher des documents"},
+		{"FR", "Rechercher", "Rechercher des documents"},
+		{"fr-CA", "Rechercher", "Rechercher des documents"},
+		{"pt-br", "Search", "Pesquisar documentos"},
+		{"pt", "Search", "Search documents"},
+		{"de", "Search", "Search documents"},
+		{"", "Search", "Search documents"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			got := tool.Localized(tt.lang)
+			if got.Title != tt.wantTitle || got.Description != tt.wantDesc {
+				t.Errorf("Localized(%q) = %q / %q, want %q / %q", tt.lang, got.Title, got.Description, tt.wantTitle, tt.wantDesc)
+			}
+		})
+	}
+
+	if tool.Localized("fr"); tool.Title != "Search" || tool.Description != "Search documents" {
+		t.Error("Localized() modified the original tool")
+	}
+
+	data, err := tool.ToMCPJSON()
+	if err != nil {
+		t.Fatalf("ToMCPJSON() error = %v", err)
+	}
+	if strings.Contains(string(data), "localizations") {
+		t.Errorf("ToMCPJSON() = %s, want localizations stripped", data)
+	}
+	data, err = tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"localizations":{"fr":`) {
+		t.Errorf("ToJSON() = %s, want localizations kept", data)
+	}
+}
+
+func TestTool_Clone(t *testing.T) {
+	destructive := true
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:        "search",
+			Meta:        mcp.Meta{"owner": map[string]any{"team": "search"}},
+			Annotations: &mcp.ToolAnnotations{DestructiveHint: &destructive},
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"q": map[string]any{"type": "string"}},
+				"required":   []any{"q"},
+			},
+			OutputSchema: json.RawMessage(`{"type":"object"}`),
+			Icons:        []mcp.Icon{{Source: "a.png", Sizes: []string{"16x16"}}},
+		},
+		Namespace:     "docs",
+		Tags:          []string{"find"},
+		Backends:      []ToolBackend{{Kind: BackendKindMCP, MCP: &MCPBackend{ServerName: "s", AuthScopes: []string{"read"}}}},
+		Localizations: map[string]ToolL10n{"fr": {Title: "Rechercher"}},
+	}
+	before, err := tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+
+	clone := tool.Clone()
+	if !reflect.DeepEqual(clone, tool) {
+		t.Fatalf("Clone() = %+v, want equal to source", clone)
+	}
+
+	input := clone.InputSchema.(map[string]any)
+	input["properties"].(map[string]any)["q"].(map[string]any)["type"] = "integer"
+	input["properties"].(map[string]any)["limit"] = map[string]any{"type": "integer"}
+	input["required"].([]any)[0] = "limit"
+	clone.OutputSchema.(json.RawMessage)[2] = 'X'
+	clone.Meta["owner"].(map[string]any)["team"] = "other"
+	*clone.Annotations.DestructiveHint = false
+	clone.Icons[0].Sizes[0] = "32x32"
+	clone.Tags[0] = "lookup"
+	clone.Backends[0].MCP.AuthScopes[0] = "write"
+	clone.Localizations["de"] = ToolL10n{Title: "Suchen"}
+
+	after, err := tool.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("mutating the clone changed the source:\n got %s\nwant %s", after, before)
+	}
+
+	typed := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: &jsonschema.Schema{
+		Type:       "object",
+		Properties: map[string]*jsonschema.Schema{"q": {Type: "string"}},
+	}}}
+	typed.Clone().InputSchema.(*jsonschema.Schema).Properties["q"].Type = "integer"
+	if got := typed.InputSchema.(*jsonschema.Schema).Properties["q"].Type; got != "string" {
+		t.Errorf("source subschema type = %q after mutating the clone, want string", got)
+	}
+
+	if (*Tool)(nil).Clone() != nil {
+		t.Error("nil.Clone() != nil")
+	}
+}
+
+func TestTool_InputSchemaJSON(t *testing.T) {
+	typed := &jsonschema.Schema{Type: "object", Required: []string{"q"}}
+	tests := []struct {
+		name   string
+		schema any
+		want   string
+	}{
+		{"map", map[string]any{"type": "object", "properties": map[string]any{"q": map[string]any{"type": "string"}}}, `{"properties":{"q":{"type":"string"}},"type":"object"}`},
+		{"raw message", json.RawMessage("{\n  \"type\": \"object\",\n  \"additionalProperties\": false\n}"), `{"type":"object","additionalProperties":false}`},
+		{"bytes", []byte(` {"type": "object"} `), `{"type":"object"}`},
+		{"jsonschema", typed, `{"type":"object","required":["q"]}`},
+		{"boolean", true, `true`},
+		{"nil", nil, `{"type":"object"}`},
+		{"json null", json.RawMessage("null"), `{"type":"object"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: tt.schema}}
+			got, err := tool.InputSchemaJSON()
+			if err != nil {
+				t.Fatalf("InputSchemaJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("InputSchemaJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	bad := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: json.RawMessage(`{"type":`)}}
+	if _, err := bad.InputSchemaJSON(); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("InputSchemaJSON() of malformed JSON error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestTool_OutputSchemaJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema any
+		want   string
+	}{
+		{"map", map[string]any{"type": "object"}, `{"type":"object"}`},
+		{"raw message", json.RawMessage(`{ "type": "object" }`), `{"type":"object"}`},
+		{"bytes", []byte(`{"type":"object", "required":["id"]}`), `{"type":"object","required":["id"]}`},
+		{"jsonschema", &jsonschema.Schema{Type: "object"}, `{"type":"object"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &Tool{Tool: mcp.Tool{Name: "t", OutputSchema: tt.schema}}
+			got, err := tool.OutputSchemaJSON()
+			if err != nil {
+				t.Fatalf("OutputSchemaJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("OutputSchemaJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, schema := range []any{nil, json.RawMessage(""), (*jsonschema.Schema)(nil)} {
+		tool := &Tool{Tool: mcp.Tool{Name: "t", OutputSchema: schema}}
+		if got, err := tool.OutputSchemaJSON(); got != nil || err != nil {
+			t.Errorf("OutputSchemaJSON() absent %T = %s, %v, want nil, nil", schema, got, err)
+		}
+	}
+}
+
+func TestTool_ToJSONWithSchemaDialect(t *testing.T) {
+	input := map[string]any{
+		"$schema":    SchemaDialect202012,
+		"type":       "object",
+		"properties": map[string]any{"q": map[string]any{"type": "string"}},
+	}
+	tool := &Tool{
+		Tool: mcp.Tool{
+			Name:         "search",
+			InputSchema:  input,
+			OutputSchema: json.RawMessage(`{"type":"object"}`),
+		},
+		Namespace: "docs",
+	}
+
+	data, err := tool.ToJSONWithSchemaDialect(SchemaDialectDraft07)
+	if err != nil {
+		t.Fatalf("ToJSONWithSchemaDialect() error = %v", err)
+	}
+	var got struct {
+		Namespace    string         `json:"namespace"`
+		InputSchema  map[string]any `json:"inputSchema"`
+		OutputSchema map[string]any `json:"outputSchema"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Namespace != "docs" {
+		t.Errorf("namespace = %q, want docs", got.Namespace)
+	}
+	if got.InputSchema["$schema"] != SchemaDialectDraft07 || got.OutputSchema["$schema"] != SchemaDialectDraft07 {
+		t.Errorf("$schema = %v / %v, want draft-07", got.InputSchema["$schema"], got.OutputSchema["$schema"])
+	}
+	if input["$schema"] != SchemaDialect202012 || string(tool.OutputSchema.(json.RawMessage)) != `{"type":"object"}` {
+		t.Error("ToJSONWithSchemaDialect() modified the tool")
+	}
+
+	if _, err := tool.ToJSONWithSchemaDialect("http://json-schema.org/draft-04/schema#"); !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("ToJSONWithSchemaDialect(draft-04) error = %v, want ErrUnsupportedSchema", err)
+	}
+}
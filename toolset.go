@@ -0,0 +1,547 @@
+package toolmodel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownTool is returned when a tool ID is not present in a ToolSet.
+var ErrUnknownTool = errors.New("unknown tool")
+
+// ToolSet is a collection of tools keyed by their canonical ToolID.
+// It is safe for concurrent use.
+type ToolSet struct {
+	mu        sync.RWMutex
+	tools     map[string]Tool
+	validator SchemaValidator
+	// byTag is an inverted index from normalized tag to tool IDs.
+	byTag map[string]map[string]struct{}
+	// lazy holds tools registered with AddLazy that have not been built yet.
+	lazy map[string]*lazyTool
+}
+
+// lazyTool is a tool built on first access by its provider.
+type lazyTool struct {
+	once    sync.Once
+	provide func() (*Tool, error)
+	tool    *Tool
+	err     error
+}
+
+// NewToolSet creates an empty ToolSet.
+func NewToolSet() *ToolSet {
+	return &ToolSet{tools: make(map[string]Tool)}
+}
+
+// Add validates tool and stores a deep copy of it (see Tool.Clone) under its
+// ToolID, replacing any tool already registered with the same ID. Later
+// changes to tool do not affect the set.
+func (s *ToolSet) Add(tool *Tool) error {
+	if tool == nil {
+		return fmt.Errorf("%w: tool is nil", ErrInvalidTool)
+	}
+	if err := tool.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tools == nil {
+		s.tools = make(map[string]Tool)
+	}
+	id := tool.ToolID()
+	if old, ok := s.tools[id]; ok {
+		s.unindexLocked(id, &old)
+	}
+	delete(s.lazy, id)
+	stored := tool.Clone()
+	s.tools[id] = *stored
+	s.indexLocked(id, stored)
+	return nil
+}
+
+// AddLazy registers a tool under id that is built by provide on the first Get
+// (or ValidateCall) for id, replacing any tool already registered with that
+// ID. provide runs at most once, even under concurrent access; its result is
+// validated and cached, and an error from it (or from validation) is returned
+// by every later access. The built tool's ToolID must equal id. Listing and
+// query methods only see the tool once it has been built.
+func (s *ToolSet) AddLazy(id string, provide func() (*Tool, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.tools[id]; ok {
+		s.unindexLocked(id, &old)
+		delete(s.tools, id)
+	}
+	if s.tools == nil {
+		s.tools = make(map[string]Tool)
+	}
+	if s.lazy == nil {
+		s.lazy = make(map[string]*lazyTool)
+	}
+	s.lazy[id] = &lazyTool{provide: provide}
+}
+
+// SetValidator sets the SchemaValidator used by ValidateCall.
+// A nil validator restores the DefaultValidator.
+func (s *ToolSet) SetValidator(v SchemaValidator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validator = v
+}
+
+// ValidateCall resolves the tool with the given ID and validates args against
+// its InputSchema. It returns an error wrapping ErrUnknownTool if the ID is not
+// registered.
+func (s *ToolSet) ValidateCall(id string, args any) error {
+	tool, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.schemaValidator().ValidateInput(tool, args)
+}
+
+// ToolCall is a single tool invocation: the ID of the tool and its arguments.
+type ToolCall struct {
+	ID   string
+	Args any
+}
+
+// ValidateBatch validates every call with ValidateCall before any is
+// executed, so an orchestrator can reject a batch as a whole. The returned
+// slice has one entry per call, in order: nil for a valid call, otherwise its
+// error (wrapping ErrUnknownTool for an unregistered ID).
+func (s *ToolSet) ValidateBatch(calls []ToolCall) []error {
+	errs := make([]error, len(calls))
+	v := s.schemaValidator()
+	for i, call := range calls {
+		tool, err := s.Get(call.ID)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		errs[i] = v.ValidateInput(tool, call.Args)
+	}
+	return errs
+}
+
+func (s *ToolSet) schemaValidator() SchemaValidator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.validator == nil {
+		return NewDefaultValidator()
+	}
+	return s.validator
+}
+
+// Remove deletes the tool with the given ID and reports whether it was present.
+func (s *ToolSet) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lazy[id]; ok {
+		delete(s.lazy, id)
+		return true
+	}
+	tool, ok := s.tools[id]
+	if !ok {
+		return false
+	}
+	s.unindexLocked(id, &tool)
+	delete(s.tools, id)
+	return true
+}
+
+// Get returns a deep copy of the tool with the given ID, building it first if
+// it was registered with AddLazy.
+// It returns ErrUnknownTool if no such tool is registered.
+func (s *ToolSet) Get(id string) (*Tool, error) {
+	s.mu.RLock()
+	tool, ok := s.tools[id]
+	entry := s.lazy[id]
+	s.mu.RUnlock()
+	if ok {
+		return tool.Clone(), nil
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, id)
+	}
+	return s.materialize(id, entry)
+}
+
+// materialize builds a lazily registered tool and installs it in the set,
+// unless the registration was replaced or removed in the meantime. An entry
+// may be shared with a snapshot, so each set installs the built tool itself.
+func (s *ToolSet) materialize(id string, entry *lazyTool) (*Tool, error) {
+	entry.once.Do(func() {
+		tool, err := entry.provide()
+		switch {
+		case err != nil:
+			entry.err = err
+		case tool == nil:
+			entry.err = fmt.Errorf("%w: provider returned nil", ErrInvalidTool)
+		case tool.ToolID() != id:
+			entry.err = fmt.Errorf("%w: provider returned %q for %q", ErrInvalidTool, tool.ToolID(), id)
+		default:
+			entry.err = tool.Validate()
+		}
+		if entry.err == nil {
+			entry.tool = tool
+		}
+	})
+	if entry.err != nil {
+		return nil, fmt.Errorf("building tool %s: %w", id, entry.err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lazy[id] == entry {
+		delete(s.lazy, id)
+		s.tools[id] = *entry.tool.Clone()
+		s.indexLocked(id, entry.tool)
+	}
+	tool, ok := s.tools[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTool, id)
+	}
+	return tool.Clone(), nil
+}
+
+// Snapshot returns an independent copy of the set: adding, removing or
+// replacing tools in either set does not affect the other, so the snapshot
+// can be passed to Restore to roll back a failed batch of changes. Each tool
+// is deep-copied with Clone, so later changes to a live tool's schemas do not
+// reach the snapshot. Lazily registered tools that have not been built yet
+// stay lazy in both sets and are built at most once.
+func (s *ToolSet) Snapshot() *ToolSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tools := make(map[string]Tool, len(s.tools))
+	for id, tool := range s.tools {
+		tools[id] = *tool.Clone()
+	}
+	return &ToolSet{
+		tools:     tools,
+		validator: s.validator,
+		byTag:     copyTagIndex(s.byTag),
+		lazy:      maps.Clone(s.lazy),
+	}
+}
+
+// Restore replaces the contents of the set, including its validator, with
+// those of snapshot. The snapshot is copied, so it stays independent and can
+// be restored again. Restoring a nil snapshot is a no-op.
+func (s *ToolSet) Restore(snapshot *ToolSet) {
+	if snapshot == nil || snapshot == s {
+		return
+	}
+	restored := snapshot.Snapshot()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = restored.tools
+	s.validator = restored.validator
+	s.byTag = restored.byTag
+	s.lazy = restored.lazy
+}
+
+func copyTagIndex(index map[string]map[string]struct{}) map[string]map[string]struct{} {
+	out := make(map[string]map[string]struct{}, len(index))
+	for tag, ids := range index {
+		out[tag] = maps.Clone(ids)
+	}
+	return out
+}
+
+// Len returns the number of tools in the set.
+func (s *ToolSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tools)
+}
+
+// List returns all tools sorted by ID.
+func (s *ToolSet) List() []Tool {
+	return s.filter(func(*Tool) bool { return true })
+}
+
+// Visible returns the tools that are not Hidden, sorted by ID.
+// Hidden tools remain resolvable through Get.
+func (s *ToolSet) Visible() []Tool {
+	return s.filter(func(t *Tool) bool { return !t.Hidden })
+}
+
+// ByTag returns the tools carrying tag, sorted by ID. The tag is normalized
+// with NormalizeTags before lookup, so "Search" matches a tool tagged "search".
+func (s *ToolSet) ByTag(tag string) []Tool {
+	normalized := NormalizeTags([]string{tag})
+	if len(normalized) == 0 {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.byTag[normalized[0]]))
+	for id := range s.byTag[normalized[0]] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]Tool, len(ids))
+	for i, id := range ids {
+		tool := s.tools[id]
+		out[i] = *tool.Clone()
+	}
+	return out
+}
+
+// ByCategory groups the tools by Category, each group sorted by ID.
+// Uncategorized tools are grouped under "".
+func (s *ToolSet) ByCategory() map[string][]Tool {
+	groups := make(map[string][]Tool)
+	for _, tool := range s.List() {
+		groups[tool.Category] = append(groups[tool.Category], tool)
+	}
+	return groups
+}
+
+// ByBackendKind returns the tools with at least one backend of kind, sorted by
+// ID.
+func (s *ToolSet) ByBackendKind(kind BackendKind) []Tool {
+	return s.filter(func(t *Tool) bool {
+		return t.HasBackendKind(kind)
+	})
+}
+
+// BackendKindHistogram counts the tools having at least one backend of each
+// kind. A tool with backends of several kinds is counted once per kind; tools
+// without backends are not counted.
+func (s *ToolSet) BackendKindHistogram() map[BackendKind]int {
+	counts := make(map[BackendKind]int)
+	for _, tool := range s.List() {
+		seen := make(map[BackendKind]bool, len(tool.Backends))
+		for _, b := range tool.Backends {
+			if !seen[b.Kind] {
+				seen[b.Kind] = true
+				counts[b.Kind]++
+			}
+		}
+	}
+	return counts
+}
+
+// ToolsRequiringField returns the tools whose InputSchema lists field in its
+// top-level "required" array, sorted by ID. Tools that merely declare the
+// field as an optional property do not match.
+func (s *ToolSet) ToolsRequiringField(field string) []Tool {
+	return s.filter(func(t *Tool) bool {
+		schema, err := schemaToMap(t.InputSchema)
+		if err != nil {
+			return false
+		}
+		for _, name := range schemaRequired(schema) {
+			if name == field {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FlatNameCollisions reports the tool Names shared by more than one tool, as
+// happens when the set is flattened into a single non-namespaced list. Each
+// colliding Name maps to the sorted IDs of the tools using it; a tool without
+// a namespace collides with namespaced tools of the same Name.
+func (s *ToolSet) FlatNameCollisions() map[string][]string {
+	byName := make(map[string][]string)
+	for _, tool := range s.List() {
+		byName[tool.Name] = append(byName[tool.Name], tool.ToolID())
+	}
+	for name, ids := range byName {
+		if len(ids) < 2 {
+			delete(byName, name)
+		}
+	}
+	return byName
+}
+
+// AllIconSources returns the distinct icon sources of every tool in the set,
+// sorted, for bulk preloading.
+func (s *ToolSet) AllIconSources() []string {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, tool := range s.List() {
+		for _, src := range tool.IconSources() {
+			if !seen[src] {
+				seen[src] = true
+				sources = append(sources, src)
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// SchemaStatsSummary aggregates the SchemaStatistics of the InputSchemas of a
+// ToolSet.
+type SchemaStatsSummary struct {
+	// Tools counts the tools whose InputSchema was analyzed.
+	Tools int
+	// Properties, Required and Enums are totals across those tools.
+	Properties int
+	Required   int
+	Enums      int
+	// MaxDepth is the deepest nesting of any analyzed InputSchema.
+	MaxDepth int
+	// ToolsUsingRefs and ToolsUsingCombinators count the tools whose
+	// InputSchema uses $ref or allOf/anyOf/oneOf.
+	ToolsUsingRefs        int
+	ToolsUsingCombinators int
+}
+
+// SchemaStatsSummary aggregates SchemaStats over the InputSchema of every
+// tool in the set, including Hidden tools. Tools whose InputSchema cannot be
+// parsed are skipped.
+func (s *ToolSet) SchemaStatsSummary() SchemaStatsSummary {
+	var summary SchemaStatsSummary
+	for _, tool := range s.List() {
+		stats, err := SchemaStats(tool.InputSchema)
+		if err != nil {
+			continue
+		}
+		summary.Tools++
+		summary.Properties += stats.Properties
+		summary.Required += stats.Required
+		summary.Enums += stats.Enums
+		summary.MaxDepth = max(summary.MaxDepth, stats.MaxDepth)
+		if stats.UsesRefs {
+			summary.ToolsUsingRefs++
+		}
+		if stats.UsesCombinators {
+			summary.ToolsUsingCombinators++
+		}
+	}
+	return summary
+}
+
+// Fingerprints returns the Fingerprint of every tool in the set, keyed by ID.
+// A tool that cannot be serialized maps to "". Lazily registered tools are
+// included once they have been built.
+func (s *ToolSet) Fingerprints() map[string]string {
+	tools := s.List()
+	out := make(map[string]string, len(tools))
+	for i := range tools {
+		fp, _ := tools[i].Fingerprint()
+		out[tools[i].ToolID()] = fp
+	}
+	return out
+}
+
+// SetFingerprint returns a hex-encoded SHA-256 digest of the (ID, Fingerprint)
+// pairs of the set, sorted by ID. It changes when a tool is added, removed or
+// modified, and is independent of insertion order.
+func (s *ToolSet) SetFingerprint() string {
+	fps := s.Fingerprints()
+	ids := make([]string, 0, len(fps))
+	for id := range fps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		fmt.Fprintf(h, "%s\x00%s\n", id, fps[id])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ChangedSince compares the set against known, a map of ID to Fingerprint
+// such as an earlier result of Fingerprints. It returns the tools that are
+// new or whose fingerprint differs, sorted by ID, and the IDs in known that
+// are no longer in the set, sorted. Lazily registered tools that have not
+// been built yet are neither changed nor removed.
+func (s *ToolSet) ChangedSince(known map[string]string) (changed []Tool, removed []string) {
+	for _, tool := range s.List() {
+		fp, _ := tool.Fingerprint()
+		if prev, ok := known[tool.ToolID()]; !ok || prev != fp {
+			changed = append(changed, tool)
+		}
+	}
+
+	s.mu.RLock()
+	for id := range known {
+		_, ok := s.tools[id]
+		if _, pending := s.lazy[id]; !ok && !pending {
+			removed = append(removed, id)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// ToMCPListResult encodes the visible tools as an MCP "tools/list" result,
+// {"tools":[...]}. Each tool is serialized like ToMCPJSON, so toolmodel
+// extensions such as namespace and tags are stripped. Tools are sorted by
+// Name, then by ID; Hidden tools are omitted.
+func (s *ToolSet) ToMCPListResult() ([]byte, error) {
+	tools := s.Visible()
+	sort.SliceStable(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	out := make([]json.RawMessage, 0, len(tools))
+	for i := range tools {
+		data, err := tools[i].ToMCPJSON()
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", tools[i].ToolID(), err)
+		}
+		out = append(out, data)
+	}
+	return json.Marshal(struct {
+		Tools []json.RawMessage `json:"tools"`
+	}{out})
+}
+
+// filter returns the tools matching keep, sorted by ID.
+func (s *ToolSet) filter(keep func(*Tool) bool) []Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.tools))
+	for id := range s.tools {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]Tool, 0, len(ids))
+	for _, id := range ids {
+		tool := s.tools[id]
+		if keep(&tool) {
+			out = append(out, *tool.Clone())
+		}
+	}
+	return out
+}
+
+// indexLocked adds tool's tags to the tag index. s.mu must be held for writing.
+func (s *ToolSet) indexLocked(id string, tool *Tool) {
+	if s.byTag == nil {
+		s.byTag = make(map[string]map[string]struct{})
+	}
+	for _, tag := range NormalizeTags(tool.Tags) {
+		ids, ok := s.byTag[tag]
+		if !ok {
+			ids = make(map[string]struct{})
+			s.byTag[tag] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// unindexLocked removes tool's tags from the tag index. s.mu must be held for
+// writing.
+func (s *ToolSet) unindexLocked(id string, tool *Tool) {
+	for _, tag := range NormalizeTags(tool.Tags) {
+		delete(s.byTag[tag], id)
+		if len(s.byTag[tag]) == 0 {
+			delete(s.byTag, tag)
+		}
+	}
+}
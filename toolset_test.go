@@ -0,0 +1,659 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func newTestTool(namespace, name string, schema any) *Tool {
+	if schema == nil {
+		schema = map[string]any{"type": "object"}
+	}
+	return &Tool{
+		Tool:      mcp.Tool{Name: name, InputSchema: schema},
+		Namespace: namespace,
+	}
+}
+
+func mustToolSet(t *testing.T, tools ...*Tool) *ToolSet {
+	t.Helper()
+	s := NewToolSet()
+	for _, tool := range tools {
+		if err := s.Add(tool); err != nil {
+			t.Fatalf("Add(%s) error = %v", tool.ToolID(), err)
+		}
+	}
+	return s
+}
+
+func toolIDs(tools []Tool) []string {
+	ids := make([]string, len(tools))
+	for i := range tools {
+		ids[i] = tools[i].ToolID()
+	}
+	return ids
+}
+
+func TestToolSet_AddGetRemove(t *testing.T) {
+	s := mustToolSet(t, newTestTool("fs", "write", nil), newTestTool("fs", "read", nil))
+
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	got, err := s.Get("fs:read")
+	if err != nil || got.Name != "read" {
+		t.Fatalf("Get() = %v, %v, want fs:read", got, err)
+	}
+	if ids := toolIDs(s.List()); len(ids) != 2 || ids[0] != "fs:read" || ids[1] != "fs:write" {
+		t.Errorf("List() = %v, want sorted by ID", ids)
+	}
+
+	if !s.Remove("fs:read") {
+		t.Error("Remove() = false, want true")
+	}
+	if s.Remove("fs:read") {
+		t.Error("Remove() of missing tool = true, want false")
+	}
+	if _, err := s.Get("fs:read"); !errors.Is(err, ErrUnknownTool) {
+		t.Errorf("Get() error = %v, want ErrUnknownTool", err)
+	}
+
+	if err := s.Add(&Tool{Tool: mcp.Tool{Name: "bad name"}}); !errors.Is(err, ErrInvalidTool) {
+		t.Errorf("Add() error = %v, want ErrInvalidTool", err)
+	}
+}
+
+func TestToolSet_ToolsRequiringField(t *testing.T) {
+	s := mustToolSet(t,
+		newTestTool("users", "get", map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"user_id": map[string]any{"type": "string"}},
+			"required":   []any{"user_id"},
+		}),
+		newTestTool("audit", "log", map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"user_id": map[string]any{"type": "string"}},
+		}),
+		newTestTool("billing", "charge", []byte(`{"type":"object","required":["amount","user_id"]}`)),
+		newTestTool("misc", "ping", nil),
+	)
+
+	got := toolIDs(s.ToolsRequiringField("user_id"))
+	want := []string{"billing:charge", "users:get"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ToolsRequiringField() = %v, want %v", got, want)
+	}
+}
+
+func TestToolSet_Visible(t *testing.T) {
+	internal := newTestTool("ops", "reindex", nil)
+	internal.Hidden = true
+	s := mustToolSet(t, newTestTool("docs", "search", nil), internal)
+
+	if ids := toolIDs(s.Visible()); len(ids) != 1 || ids[0] != "docs:search" {
+		t.Errorf("Visible() = %v, want [docs:search]", ids)
+	}
+	got, err := s.Get("ops:reindex")
+	if err != nil || !got.Hidden {
+		t.Errorf("Get() = %v, %v, want hidden tool", got, err)
+	}
+}
+
+func TestToolSet_ValidateCall(t *testing.T) {
+	s := mustToolSet(t, newTestTool("docs", "search", map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"query": map[string]any{"type": "string"}},
+		"required":   []any{"query"},
+	}))
+
+	if err := s.ValidateCall("docs:search", map[string]any{"query": "go"}); err != nil {
+		t.Errorf("ValidateCall() unexpected error: %v", err)
+	}
+	if err := s.ValidateCall("docs:missing", map[string]any{}); !errors.Is(err, ErrUnknownTool) {
+		t.Errorf("ValidateCall() error = %v, want ErrUnknownTool", err)
+	}
+	err := s.ValidateCall("docs:search", map[string]any{"query": 42})
+	if err == nil || errors.Is(err, ErrUnknownTool) {
+		t.Errorf("ValidateCall() error = %v, want validation error", err)
+	}
+
+	t.Run("custom validator", func(t *testing.T) {
+		cv := &contractValidator{}
+		s.SetValidator(cv)
+		defer s.SetValidator(nil)
+		if err := s.ValidateCall("docs:search", map[string]any{"query": 42}); err != nil {
+			t.Errorf("ValidateCall() error = %v, want nil from custom validator", err)
+		}
+		if cv.lastInstance == nil {
+			t.Error("custom validator was not used")
+		}
+	})
+}
+
+func TestToolSet_ValidateBatch(t *testing.T) {
+	s := mustToolSet(t, newTestTool("docs", "search", map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"query": map[string]any{"type": "string"}},
+		"required":   []any{"query"},
+	}))
+
+	errs := s.ValidateBatch([]ToolCall{
+		{ID: "docs:search", Args: map[string]any{"query": "go"}},
+		{ID: "docs:search", Args: map[string]any{"query": 42}},
+		{ID: "docs:missing", Args: map[string]any{}},
+		{ID: "docs:search", Args: map[string]any{"query": "rust"}},
+	})
+	if len(errs) != 4 {
+		t.Fatalf("ValidateBatch() returned %d errors, want 4", len(errs))
+	}
+	if errs[0] != nil || errs[3] != nil {
+		t.Errorf("ValidateBatch() valid calls = %v, %v, want nil", errs[0], errs[3])
+	}
+	var ve *ValidationError
+	if !errors.As(errs[1], &ve) {
+		t.Errorf("ValidateBatch()[1] = %v, want *ValidationError", errs[1])
+	}
+	if !errors.Is(errs[2], ErrUnknownTool) {
+		t.Errorf("ValidateBatch()[2] = %v, want ErrUnknownTool", errs[2])
+	}
+
+	if errs := s.ValidateBatch(nil); len(errs) != 0 {
+		t.Errorf("ValidateBatch(nil) = %v, want empty", errs)
+	}
+}
+
+func TestToolSet_ByTag(t *testing.T) {
+	search := newTestTool("docs", "search", nil)
+	search.Tags = []string{"Search", "Docs"}
+	grep := newTestTool("fs", "grep", nil)
+	grep.Tags = []string{"search"}
+	s := mustToolSet(t, search, grep)
+
+	if ids := toolIDs(s.ByTag(" SEARCH ")); len(ids) != 2 || ids[0] != "docs:search" || ids[1] != "fs:grep" {
+		t.Errorf("ByTag(search) = %v, want [docs:search fs:grep]", ids)
+	}
+
+	s.Remove("fs:grep")
+	if ids := toolIDs(s.ByTag("search")); len(ids) != 1 || ids[0] != "docs:search" {
+		t.Errorf("ByTag(search) after Remove = %v, want [docs:search]", ids)
+	}
+
+	// Replacing a tool re-indexes its tags.
+	retagged := newTestTool("docs", "search", nil)
+	retagged.Tags = []string{"lookup"}
+	if err := s.Add(retagged); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if got := s.ByTag("search"); len(got) != 0 {
+		t.Errorf("ByTag(search) after retag = %v, want none", toolIDs(got))
+	}
+	if ids := toolIDs(s.ByTag("Lookup")); len(ids) != 1 || ids[0] != "docs:search" {
+		t.Errorf("ByTag(lookup) = %v, want [docs:search]", ids)
+	}
+	if got := s.ByTag("###"); got != nil {
+		t.Errorf("ByTag(invalid) = %v, want nil", got)
+	}
+}
+
+func TestToolSet_ByCategory(t *testing.T) {
+	calendar := newTestTool("gcal", "create", nil)
+	calendar.Category = "Productivity"
+	notes := newTestTool("notes", "add", nil)
+	notes.Category = "Productivity"
+	query := newTestTool("db", "query", nil)
+	query.Category = "Data"
+	s := mustToolSet(t, calendar, notes, query, newTestTool("misc", "ping", nil))
+
+	groups := s.ByCategory()
+	if len(groups) != 3 {
+		t.Fatalf("ByCategory() has %d groups, want 3", len(groups))
+	}
+	if ids := toolIDs(groups["Productivity"]); len(ids) != 2 || ids[0] != "gcal:create" || ids[1] != "notes:add" {
+		t.Errorf("Productivity = %v, want [gcal:create notes:add]", ids)
+	}
+	if ids := toolIDs(groups["Data"]); len(ids) != 1 || ids[0] != "db:query" {
+		t.Errorf("Data = %v, want [db:query]", ids)
+	}
+	if ids := toolIDs(groups[""]); len(ids) != 1 || ids[0] != "misc:ping" {
+		t.Errorf("uncategorized = %v, want [misc:ping]", ids)
+	}
+}
+
+func TestToolSet_ByBackendKind(t *testing.T) {
+	mcpBackend := ToolBackend{Kind: BackendKindMCP, MCP: &MCPBackend{ServerName: "fs"}}
+	localBackend := ToolBackend{Kind: BackendKindLocal, Local: &LocalBackend{Name: "handler"}}
+	providerBackend := ToolBackend{Kind: BackendKindProvider, Provider: &ProviderBackend{ProviderID: "p", ToolID: "t"}}
+
+	read := newTestTool("fs", "read", nil)
+	read.Backends = []ToolBackend{mcpBackend, localBackend}
+	write := newTestTool("fs", "write", nil)
+	write.Backends = []ToolBackend{mcpBackend, mcpBackend}
+	search := newTestTool("web", "search", nil)
+	search.Backends = []ToolBackend{providerBackend}
+	bare := newTestTool("", "echo", nil)
+
+	s := mustToolSet(t, write, search, read, bare)
+
+	if got, want := toolIDs(s.ByBackendKind(BackendKindMCP)), []string{"fs:read", "fs:write"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByBackendKind(mcp) = %v, want %v", got, want)
+	}
+	if got, want := toolIDs(s.ByBackendKind(BackendKindLocal)), []string{"fs:read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByBackendKind(local) = %v, want %v", got, want)
+	}
+
+	want := map[BackendKind]int{BackendKindMCP: 2, BackendKindLocal: 1, BackendKindProvider: 1}
+	if got := s.BackendKindHistogram(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BackendKindHistogram() = %v, want %v", got, want)
+	}
+}
+
+func TestToolSet_AddRejectsInvalidBackend(t *testing.T) {
+	tool := newTestTool("fs", "read", nil)
+	tool.Backends = []ToolBackend{{Kind: BackendKindMCP}}
+	err := NewToolSet().Add(tool)
+	if !errors.Is(err, ErrInvalidTool) || !errors.Is(err, ErrInvalidBackend) {
+		t.Errorf("Add() error = %v, want ErrInvalidTool and ErrInvalidBackend", err)
+	}
+}
+
+func TestToolSet_ToMCPListResult(t *testing.T) {
+	search := newTestTool("web", "search", nil)
+	search.Tags = []string{"search"}
+	read := newTestTool("fs", "read", nil)
+	read.Version = "1.0.0"
+	hidden := newTestTool("fs", "debug", nil)
+	hidden.Hidden = true
+	s := mustToolSet(t, search, read, hidden)
+
+	data, err := s.ToMCPListResult()
+	if err != nil {
+		t.Fatalf("ToMCPListResult() error = %v", err)
+	}
+	var result map[string][]map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("ToMCPListResult() returned invalid JSON: %v", err)
+	}
+	tools, ok := result["tools"]
+	if !ok || len(result) != 1 {
+		t.Fatalf("ToMCPListResult() = %s, want a single tools array", data)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool["name"].(string))
+		for _, ext := range []string{"namespace", "tags", "version"} {
+			if _, ok := tool[ext]; ok {
+				t.Errorf("tool %v has extension field %q", tool["name"], ext)
+			}
+		}
+	}
+	if want := []string{"read", "search"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tool names = %v, want %v", names, want)
+	}
+
+	empty, err := NewToolSet().ToMCPListResult()
+	if err != nil || string(empty) != `{"tools":[]}` {
+		t.Errorf("ToMCPListResult() on empty set = %s, %v", empty, err)
+	}
+}
+
+func TestToolSet_AllIconSources(t *testing.T) {
+	a := newTestTool("web", "search", nil)
+	a.Icons = []mcp.Icon{{Source: "https://example.com/b.png"}, {Source: "https://example.com/a.png"}}
+	b := newTestTool("web", "fetch", nil)
+	b.Icons = []mcp.Icon{{Source: "https://example.com/a.png"}}
+	c := newTestTool("fs", "read", nil)
+	s := mustToolSet(t, a, b, c)
+
+	want := []string{"https://example.com/a.png", "https://example.com/b.png"}
+	if got := s.AllIconSources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllIconSources() = %v, want %v", got, want)
+	}
+}
+
+func TestToolSet_AddLazy(t *testing.T) {
+	t.Run("provider runs once under concurrency", func(t *testing.T) {
+		s := NewToolSet()
+		var calls atomic.Int32
+		s.AddLazy("docs:search", func() (*Tool, error) {
+			calls.Add(1)
+			tool := newTestTool("docs", "search", nil)
+			tool.Tags = []string{"search"}
+			return tool, nil
+		})
+		if s.Len() != 0 {
+			t.Errorf("Len() before first Get = %d, want 0", s.Len())
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 32; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if tool, err := s.Get("docs:search"); err != nil || tool.Name != "search" {
+					t.Errorf("Get() = %v, %v", tool, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := calls.Load(); got != 1 {
+			t.Errorf("provider called %d times, want 1", got)
+		}
+		if got := toolIDs(s.ByTag("search")); !reflect.DeepEqual(got, []string{"docs:search"}) {
+			t.Errorf("ByTag() after build = %v", got)
+		}
+	})
+
+	t.Run("provider error is surfaced and cached", func(t *testing.T) {
+		s := NewToolSet()
+		errBoom := errors.New("schema generation failed")
+		var calls atomic.Int32
+		s.AddLazy("docs:search", func() (*Tool, error) {
+			calls.Add(1)
+			return nil, errBoom
+		})
+		for i := 0; i < 2; i++ {
+			if _, err := s.Get("docs:search"); !errors.Is(err, errBoom) {
+				t.Errorf("Get() error = %v, want provider error", err)
+			}
+		}
+		if err := s.ValidateCall("docs:search", map[string]any{}); !errors.Is(err, errBoom) {
+			t.Errorf("ValidateCall() error = %v, want provider error", err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("provider called %d times, want 1", got)
+		}
+	})
+
+	t.Run("mismatched ID and removal", func(t *testing.T) {
+		s := NewToolSet()
+		s.AddLazy("docs:search", func() (*Tool, error) {
+			return newTestTool("docs", "other", nil), nil
+		})
+		if _, err := s.Get("docs:search"); !errors.Is(err, ErrInvalidTool) {
+			t.Errorf("Get() error = %v, want ErrInvalidTool", err)
+		}
+		if !s.Remove("docs:search") {
+			t.Error("Remove() = false for lazy tool")
+		}
+		if _, err := s.Get("docs:search"); !errors.Is(err, ErrUnknownTool) {
+			t.Errorf("Get() after Remove error = %v, want ErrUnknownTool", err)
+		}
+	})
+}
+
+func TestToolSet_SchemaStatsSummary(t *testing.T) {
+	search := newTestTool("web", "search", map[string]any{
+		"type":     "object",
+		"required": []any{"q"},
+		"properties": map[string]any{
+			"q":    map[string]any{"type": "string"},
+			"sort": map[string]any{"enum": []any{"asc", "desc"}},
+		},
+	})
+	fetch := newTestTool("web", "fetch", map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{"$ref": "#/$defs/url"},
+		},
+		"$defs": map[string]any{"url": map[string]any{"type": "string"}},
+	})
+	ping := newTestTool("", "ping", nil)
+	ping.Hidden = true
+	s := mustToolSet(t, search, fetch, ping)
+
+	want := SchemaStatsSummary{
+		Tools:          3,
+		Properties:     3,
+		Required:       1,
+		Enums:          1,
+		MaxDepth:       1,
+		ToolsUsingRefs: 1,
+	}
+	if got := s.SchemaStatsSummary(); got != want {
+		t.Errorf("SchemaStatsSummary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestToolSet_SetFingerprint(t *testing.T) {
+	search := newTestTool("web", "search", nil)
+	fetch := newTestTool("web", "fetch", nil)
+	s := mustToolSet(t, search, fetch)
+
+	fps := s.Fingerprints()
+	want, err := search.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	if len(fps) != 2 || fps["web:search"] != want {
+		t.Errorf("Fingerprints() = %v, want 2 entries with web:search = %s", fps, want)
+	}
+
+	base := s.SetFingerprint()
+	if again := mustToolSet(t, fetch, search).SetFingerprint(); again != base {
+		t.Errorf("SetFingerprint() depends on insertion order: %s != %s", again, base)
+	}
+
+	seen := map[string]string{base: "base"}
+	check := func(step string) {
+		t.Helper()
+		fp := s.SetFingerprint()
+		if prev, ok := seen[fp]; ok {
+			t.Errorf("SetFingerprint() after %s equals the one after %s", step, prev)
+		}
+		seen[fp] = step
+	}
+
+	if err := s.Add(newTestTool("fs", "read", nil)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	check("add")
+
+	changed := newTestTool("web", "search", nil)
+	changed.Description = "Search the web"
+	if err := s.Add(changed); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	check("change")
+
+	s.Remove("fs:read")
+	check("remove")
+}
+
+func TestToolSet_ChangedSince(t *testing.T) {
+	search := newTestTool("web", "search", nil)
+	fetch := newTestTool("web", "fetch", nil)
+	s := mustToolSet(t, search, fetch, newTestTool("fs", "read", nil))
+	known := s.Fingerprints()
+
+	changed := newTestTool("web", "fetch", nil)
+	changed.Description = "Fetch a URL"
+	if err := s.Add(changed); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add(newTestTool("fs", "write", nil)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	s.Remove("fs:read")
+	s.AddLazy("web:crawl", func() (*Tool, error) { return newTestTool("web", "crawl", nil), nil })
+	known["web:crawl"] = "stale"
+
+	gotChanged, gotRemoved := s.ChangedSince(known)
+	var ids []string
+	for _, tool := range gotChanged {
+		ids = append(ids, tool.ToolID())
+	}
+	if want := []string{"fs:write", "web:fetch"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ChangedSince() changed = %v, want %v", ids, want)
+	}
+	if want := []string{"fs:read"}; !reflect.DeepEqual(gotRemoved, want) {
+		t.Errorf("ChangedSince() removed = %v, want %v", gotRemoved, want)
+	}
+
+	gotChanged, gotRemoved = s.ChangedSince(s.Fingerprints())
+	if len(gotChanged) != 0 || len(gotRemoved) != 0 {
+		t.Errorf("ChangedSince(current) = %v, %v, want nothing", gotChanged, gotRemoved)
+	}
+}
+
+func TestToolSet_SnapshotRestore(t *testing.T) {
+	search := newTestTool("web", "search", nil)
+	search.Tags = []string{"find"}
+	s := mustToolSet(t, search, newTestTool("web", "fetch", nil))
+
+	snap := s.Snapshot()
+
+	// Mutating the snapshot leaves the live set alone.
+	if err := snap.Add(newTestTool("fs", "read", nil)); err != nil {
+		t.Fatalf("snapshot Add() error = %v", err)
+	}
+	snap.Remove("web:fetch")
+	if s.Len() != 2 || snap.Len() != 2 {
+		t.Fatalf("Len() live = %d, snapshot = %d, want 2 and 2", s.Len(), snap.Len())
+	}
+	if _, err := s.Get("fs:read"); !errors.Is(err, ErrUnknownTool) {
+		t.Errorf("live Get(fs:read) error = %v, want ErrUnknownTool", err)
+	}
+
+	// A failed batch on the live set is rolled back.
+	before := s.Snapshot()
+	changed := newTestTool("web", "search", nil)
+	changed.Tags = []string{"lookup"}
+	if err := s.Add(changed); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	s.Remove("web:fetch")
+	if err := s.Add(&Tool{Tool: mcp.Tool{Name: "bad name"}}); err == nil {
+		t.Fatal("Add() of an invalid tool error = nil")
+	}
+	s.Restore(before)
+
+	ids := func(tools []Tool) []string {
+		var out []string
+		for _, tool := range tools {
+			out = append(out, tool.ToolID())
+		}
+		return out
+	}
+	if got, want := ids(s.List()), []string{"web:fetch", "web:search"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() after Restore = %v, want %v", got, want)
+	}
+	if got := ids(s.ByTag("find")); !reflect.DeepEqual(got, []string{"web:search"}) {
+		t.Errorf("ByTag(find) after Restore = %v, want [web:search]", got)
+	}
+	if got := s.ByTag("lookup"); len(got) != 0 {
+		t.Errorf("ByTag(lookup) after Restore = %v, want none", ids(got))
+	}
+
+	// Restore copies, so the snapshot stays independent afterwards.
+	s.Remove("web:search")
+	if before.Len() != 2 {
+		t.Errorf("snapshot Len() after live Remove = %d, want 2", before.Len())
+	}
+}
+
+func TestToolSet_StoresCopies(t *testing.T) {
+	props := map[string]any{"q": map[string]any{"type": "string"}}
+	tool := newTestTool("web", "search", map[string]any{"type": "object", "properties": props})
+	tool.Tags = []string{"alpha"}
+	s := mustToolSet(t, tool)
+
+	tool.Tags[0] = "beta"
+	props["q"].(map[string]any)["type"] = "integer"
+
+	got, err := s.Get("web:search")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"alpha"}) {
+		t.Errorf("Get() Tags = %v after changing the added tool, want [alpha]", got.Tags)
+	}
+	if typ := got.InputSchema.(map[string]any)["properties"].(map[string]any)["q"].(map[string]any)["type"]; typ != "string" {
+		t.Errorf("Get() property type = %v after changing the added tool, want string", typ)
+	}
+	if n := len(s.ByTag("alpha")); n != 1 {
+		t.Errorf("ByTag(alpha) returned %d tools, want 1", n)
+	}
+
+	got.Tags[0] = "gamma"
+	got.InputSchema.(map[string]any)["type"] = "array"
+	for _, listed := range [][]Tool{s.List(), s.ByTag("alpha")} {
+		if len(listed) != 1 || listed[0].Tags[0] != "alpha" || listed[0].InputSchema.(map[string]any)["type"] != "object" {
+			t.Errorf("stored tool changed through a Get copy: %+v", listed)
+		}
+	}
+}
+
+func TestToolSet_SnapshotIsDeep(t *testing.T) {
+	props := map[string]any{"q": map[string]any{"type": "string"}}
+	tool := newTestTool("web", "search", map[string]any{"type": "object", "properties": props})
+	s := mustToolSet(t, tool)
+
+	snap := s.Snapshot()
+	props["q"].(map[string]any)["type"] = "integer"
+	props["limit"] = map[string]any{"type": "integer"}
+
+	got, err := snap.Get("web:search")
+	if err != nil {
+		t.Fatalf("snapshot Get() error = %v", err)
+	}
+	want := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"q": map[string]any{"type": "string"}},
+	}
+	if !reflect.DeepEqual(got.InputSchema, want) {
+		t.Errorf("snapshot InputSchema = %v, want %v", got.InputSchema, want)
+	}
+
+	s.Restore(nil)
+	if s.Len() != 1 {
+		t.Errorf("Len() after Restore(nil) = %d, want 1", s.Len())
+	}
+}
+
+func TestToolSet_SnapshotLazy(t *testing.T) {
+	var calls atomic.Int32
+	s := NewToolSet()
+	s.AddLazy("web:search", func() (*Tool, error) {
+		calls.Add(1)
+		return newTestTool("web", "search", nil), nil
+	})
+	snap := s.Snapshot()
+
+	if _, err := snap.Get("web:search"); err != nil {
+		t.Fatalf("snapshot Get() error = %v", err)
+	}
+	if _, err := s.Get("web:search"); err != nil {
+		t.Fatalf("live Get() error = %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("provider calls = %d, want 1", n)
+	}
+	if s.Len() != 1 || snap.Len() != 1 {
+		t.Errorf("Len() live = %d, snapshot = %d, want 1 and 1", s.Len(), snap.Len())
+	}
+}
+
+func TestToolSet_FlatNameCollisions(t *testing.T) {
+	s := mustToolSet(t,
+		newTestTool("a", "read", nil),
+		newTestTool("b", "read", nil),
+		newTestTool("", "read", nil),
+		newTestTool("a", "write", nil),
+		newTestTool("b", "list", nil),
+	)
+	want := map[string][]string{"read": {"a:read", "b:read", "read"}}
+	if got := s.FlatNameCollisions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FlatNameCollisions() = %v, want %v", got, want)
+	}
+
+	if got := mustToolSet(t, newTestTool("a", "read", nil)).FlatNameCollisions(); len(got) != 0 {
+		t.Errorf("FlatNameCollisions() = %v, want none", got)
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/jsonschema-go/jsonschema"
 )
@@ -20,6 +26,10 @@ var (
 
 	// ErrInvalidSchema is returned when a schema is not a valid JSON Schema object.
 	ErrInvalidSchema = errors.New("invalid JSON Schema")
+
+	// ErrInstanceTooLarge is returned when an instance exceeds the size set
+	// with WithMaxInstanceBytes.
+	ErrInstanceTooLarge = errors.New("instance exceeds maximum size")
 )
 
 // Supported JSON Schema dialects.
@@ -59,43 +69,429 @@ type SchemaValidator interface {
 // It supports JSON Schema 2020-12 (default) and draft-07.
 // External $ref resolution is disabled to prevent network access.
 //
+// Conditional keywords are enforced: "if"/"then"/"else", "dependentRequired",
+// and "dependentSchemas".
+//
+// When several required properties are missing, they are reported in the
+// order declared by the schema's "required" array.
+//
 // Limitations (from jsonschema-go):
-//   - The "format" keyword is not validated by default (treated as annotation)
+//   - The "format" keyword is not validated by default (treated as annotation);
+//     see WithFormatAssertion
 //   - Content-related keywords (contentEncoding, contentMediaType) are not validated
-type DefaultValidator struct{}
+type DefaultValidator struct {
+	maxInstanceBytes  int
+	sharedInputDefs   bool
+	rejectExternalIDs bool
+	allErrors         bool
+	assertFormats     bool
+	customFormats     map[string]func(string) error
+	cache             *schemaCache
+}
+
+// ValidatorOption configures a DefaultValidator.
+type ValidatorOption func(*DefaultValidator)
+
+// WithMaxInstanceBytes rejects instances whose JSON encoding is larger than n
+// bytes with ErrInstanceTooLarge, before any schema validation. n <= 0
+// disables the limit (the default).
+func WithMaxInstanceBytes(n int) ValidatorOption {
+	return func(v *DefaultValidator) {
+		v.maxInstanceBytes = n
+	}
+}
+
+// WithSharedInputDefs makes ValidateOutput resolve the OutputSchema with the
+// InputSchema's "$defs" (and "definitions") in scope, so an output schema can
+// refer to shared definitions declared on the input, e.g. "#/$defs/item".
+// Definitions declared by the OutputSchema itself take precedence. External
+// references stay blocked.
+func WithSharedInputDefs() ValidatorOption {
+	return func(v *DefaultValidator) {
+		v.sharedInputDefs = true
+	}
+}
+
+// WithRejectExternalIDs makes the validator reject schemas containing an
+// "$id" that is an absolute http or https URI, returning an error wrapping
+// ErrExternalRef. Such an $id would rebase relative "$ref"s onto a remote
+// location. Other identifiers, such as urn: URIs, remain allowed.
+func WithRejectExternalIDs() ValidatorOption {
+	return func(v *DefaultValidator) {
+		v.rejectExternalIDs = true
+	}
+}
+
+// WithAllErrors makes Validate, ValidateInput and ValidateOutput report every
+// failure instead of one: the returned *ValidationError's message lists each
+// entry of its Errors on its own line, in the order of ValidateAll.
+func WithAllErrors() ValidatorOption {
+	return func(v *DefaultValidator) {
+		v.allErrors = true
+	}
+}
+
+// WithFormatAssertion turns assertion of the "format" keyword on or off. When
+// on, string values are checked against the standard formats "email", "uri",
+// "date-time", "ipv4", "ipv6", "uuid" and "hostname", and a mismatch fails
+// validation with keyword "format". Other formats stay annotations. Formats
+// are asserted in subschemas reached through "properties",
+// "patternProperties", "additionalProperties", items keywords, local "$ref",
+// "allOf", "if"/"then"/"else", "anyOf", "oneOf" and "not"; a branch of the
+// last three matches only if its formats hold too. Assertion is off by
+// default.
+func WithFormatAssertion(enabled bool) ValidatorOption {
+	return func(v *DefaultValidator) {
+		v.assertFormats = enabled
+	}
+}
+
+// WithCustomFormat registers fn as the checker for the "format" value name,
+// e.g. "semver" or "cron", replacing a standard checker of the same name.
+// It only takes effect when WithFormatAssertion is enabled; a non-nil error
+// from fn then fails validation with keyword "format" like any standard
+// format. Formats with no checker remain annotations.
+//
+// The validator may call fn from several goroutines at once, so fn must be
+// safe for concurrent use. Registrations are fixed once NewDefaultValidator
+// returns.
+func WithCustomFormat(name string, fn func(string) error) ValidatorOption {
+	return func(v *DefaultValidator) {
+		// Copy so validators derived with ValidateInputOpt never share writes.
+		formats := maps.Clone(v.customFormats)
+		if formats == nil {
+			formats = make(map[string]func(string) error)
+		}
+		formats[name] = fn
+		v.customFormats = formats
+	}
+}
+
+// WithSchemaCacheSize memoizes up to n resolved schemas, keyed by a SHA-256
+// hash of their JSON encoding, so repeated validation against an identical
+// schema skips parsing, reference checks and resolution, including that of
+// the keyword checks behind format assertion and error details. The least
+// recently used schema is evicted once n is exceeded. Schemas that fail to
+// resolve are not cached. The cache is safe for concurrent use and is shared
+// by the validators derived through ValidateInputOpt and ValidateOutputOpt.
+// n <= 0 disables caching (the default).
+func WithSchemaCacheSize(n int) ValidatorOption {
+	return func(v *DefaultValidator) {
+		if n <= 0 {
+			v.cache = nil
+			return
+		}
+		v.cache = newSchemaCache(n)
+	}
+}
 
 // NewDefaultValidator creates a new DefaultValidator.
-func NewDefaultValidator() *DefaultValidator {
-	return &DefaultValidator{}
+func NewDefaultValidator(opts ...ValidatorOption) *DefaultValidator {
+	v := &DefaultValidator{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
 }
 
-// Validate validates an instance against a JSON Schema.
+// formatCheckers returns the checkers asserted by WithFormatAssertion: the
+// standard formats overlaid with those registered by WithCustomFormat.
+func (v *DefaultValidator) formatCheckers() map[string]func(string) error {
+	if len(v.customFormats) == 0 {
+		return standardFormats
+	}
+	formats := maps.Clone(standardFormats)
+	maps.Copy(formats, v.customFormats)
+	return formats
+}
+
+// Validate validates an instance against a JSON Schema. A failing instance is
+// reported as a *ValidationError; schema problems wrap ErrInvalidSchema,
+// ErrUnsupportedSchema or ErrExternalRef.
 func (v *DefaultValidator) Validate(schema any, instance any) error {
-	// Convert schema to jsonschema.Schema
-	jsSchema, err := v.toJSONSchema(schema)
+	if err := v.checkInstanceSize(instance); err != nil {
+		return err
+	}
+	compiled, err := v.resolve(schema)
 	if err != nil {
 		return err
 	}
 
-	// Check $schema dialect
-	if err := v.checkDialect(jsSchema); err != nil {
+	// Validate the instance
+	if err := compiled.resolved.Validate(instance); err != nil {
+		// Locate every failure for the structured error.
+		violations, verr := v.violationsOf(compiled, instance)
+		if v.assertFormats && verr == nil && len(violations) == 0 {
+			// Asserted formats overturned every failure, e.g. a "not"
+			// whose branch only fails on its format.
+			return nil
+		}
+		ve := newValidationError(violations, err)
+		ve.all = v.allErrors
+		return ve
+	}
+
+	// jsonschema-go treats "format" as an annotation; assert it separately.
+	if v.assertFormats {
+		violations, err := v.violationsOf(compiled, instance)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			ve := newValidationError(violations, nil)
+			ve.all = v.allErrors
+			return ve
+		}
+	}
+
+	return nil
+}
+
+// ValidateAll validates instance against schema and returns every failure,
+// one *ValidationError each (the same entries a failed Validate lists in
+// ValidationError.Errors), or nil when instance is valid. The order is
+// deterministic: object properties are visited in sorted order, array items
+// by index, and missing required properties in the order the schema declares
+// them. A schema problem or oversized instance is returned as the only
+// error.
+func (v *DefaultValidator) ValidateAll(schema any, instance any) []error {
+	if err := v.checkInstanceSize(instance); err != nil {
+		return []error{err}
+	}
+	violations, err := v.violations(schema, instance)
+	if err != nil {
+		return []error{err}
+	}
+	return validationErrors(violations)
+}
+
+// ValidateInputOpt is ValidateInput with opts applied on top of the
+// validator's own options for this call only, e.g. WithAllErrors for
+// form-style reporting.
+func (v *DefaultValidator) ValidateInputOpt(tool *Tool, args any, opts ...ValidatorOption) error {
+	return v.with(opts).ValidateInput(tool, args)
+}
+
+// ValidateOutputOpt is ValidateOutput with opts applied on top of the
+// validator's own options for this call only.
+func (v *DefaultValidator) ValidateOutputOpt(tool *Tool, result any, opts ...ValidatorOption) error {
+	return v.with(opts).ValidateOutput(tool, result)
+}
+
+// with returns a copy of v with opts applied.
+func (v *DefaultValidator) with(opts []ValidatorOption) *DefaultValidator {
+	c := *v
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// ValidateIgnoring validates instance against a copy of schema from which the
+// named keywords (e.g. "format", "pattern") have been removed from every
+// subschema, for lenient validation modes. Only keywords are removed, never
+// properties that happen to share their names; the caller's schema is not
+// modified.
+func (v *DefaultValidator) ValidateIgnoring(schema any, instance any, ignore ...string) error {
+	if _, ok := booleanSchema(schema); ok || len(ignore) == 0 {
+		return v.Validate(schema, instance)
+	}
+	m, err := schemaToMap(schema)
+	if err != nil {
 		return err
 	}
+	walkSchema(m, func(_ string, node map[string]any) bool {
+		for _, kw := range ignore {
+			delete(node, kw)
+		}
+		return true
+	})
+	return v.Validate(m, instance)
+}
+
+// ValidateReader decodes a JSON instance from r and validates it against
+// schema. With WithMaxInstanceBytes set, at most that many bytes (plus one, to
+// detect overflow) are read, so oversized input is rejected with
+// ErrInstanceTooLarge without buffering it.
+func (v *DefaultValidator) ValidateReader(schema any, r io.Reader) error {
+	if v.maxInstanceBytes > 0 {
+		r = io.LimitReader(r, int64(v.maxInstanceBytes)+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("validation failed: reading instance: %w", err)
+	}
+	if v.maxInstanceBytes > 0 && len(data) > v.maxInstanceBytes {
+		return fmt.Errorf("%w: more than %d bytes", ErrInstanceTooLarge, v.maxInstanceBytes)
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return fmt.Errorf("validation failed: decoding instance: %w", err)
+	}
+	return v.Validate(schema, instance)
+}
+
+// checkInstanceSize enforces WithMaxInstanceBytes.
+func (v *DefaultValidator) checkInstanceSize(instance any) error {
+	if v.maxInstanceBytes <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("validation failed: encoding instance: %w", err)
+	}
+	if len(data) > v.maxInstanceBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrInstanceTooLarge, len(data), v.maxInstanceBytes)
+	}
+	return nil
+}
+
+// FirstError validates instance against schema and locates the first failure.
+// pointer is the JSON Pointer of the failing instance node (for example
+// "/items/2/name", or "" for the root) and keyword is the schema keyword that
+// failed. Object properties are visited in sorted order and array items by
+// index, so the result is deterministic. All results are empty when instance
+// is valid; schema problems are returned as err with an empty pointer.
+func (v *DefaultValidator) FirstError(schema any, instance any) (pointer string, keyword string, err error) {
+	violations, err := v.violations(schema, instance)
+	if err != nil || len(violations) == 0 {
+		return "", "", err
+	}
+	first := violations[0]
+	return first.instancePath, first.keyword, validationErrors(violations[:1])[0]
+}
+
+// ValidateSchema checks that schema is a well-formed JSON Schema that the
+// DefaultValidator can use, without validating any instance. In addition to
+// parsing and dialect checks, every same-document "$ref" must point at an
+// existing node; a dangling reference such as "#/$defs/missing" returns
+// ErrInvalidSchema naming the pointer. References that loop back to the same
+// node without descending into the instance (e.g. two definitions referring to
+// each other) are rejected the same way, while recursion through "properties"
+// or "items" is allowed. External references return ErrExternalRef.
+func ValidateSchema(schema any) error {
+	_, err := NewDefaultValidator().resolve(schema)
+	return err
+}
+
+// compiledSchema is a schema ready for validation: the resolved schema and
+// the index of its checked JSON document, which the violation collector
+// walks.
+type compiledSchema struct {
+	resolved *jsonschema.Resolved
+	index    *schemaIndex
+
+	collectorOnce sync.Once
+	base          *violationCollector
+	baseErr       error
+}
+
+// collector returns a violation collector for the schema. Collectors from
+// the same compiled schema share its resolved keyword checks, so a cached
+// schema resolves each check once across calls.
+func (cs *compiledSchema) collector() (*violationCollector, error) {
+	cs.collectorOnce.Do(func() {
+		cs.base, cs.baseErr = newViolationCollector(cs.index.root)
+	})
+	if cs.baseErr != nil {
+		return nil, cs.baseErr
+	}
+	c := *cs.base
+	c.patterns = make(map[string]*regexp.Regexp)
+	return &c, nil
+}
+
+// resolve converts, checks, and resolves schema for validation, reusing a
+// cached result when WithSchemaCacheSize is set.
+func (v *DefaultValidator) resolve(schema any) (*compiledSchema, error) {
+	if v.cache == nil {
+		return v.compile(schema)
+	}
+	key, ok := v.schemaCacheKey(schema)
+	if !ok {
+		return v.compile(schema)
+	}
+	if compiled, ok := v.cache.get(key); ok {
+		return compiled, nil
+	}
+	compiled, err := v.compile(schema)
+	if err != nil {
+		return nil, err
+	}
+	v.cache.put(key, compiled)
+	return compiled, nil
+}
+
+// compile converts, checks, and resolves schema without consulting the cache.
+func (v *DefaultValidator) compile(schema any) (*compiledSchema, error) {
+	jsSchema, ix, err := v.prepare(schema)
+	if err != nil {
+		return nil, err
+	}
 
 	// Resolve the schema with a loader that blocks external refs
 	resolved, err := jsSchema.Resolve(&jsonschema.ResolveOptions{
 		Loader: v.blockExternalRefs,
 	})
 	if err != nil {
-		return fmt.Errorf("schema resolution failed: %w", err)
+		return nil, fmt.Errorf("schema resolution failed: %w", err)
 	}
+	return &compiledSchema{resolved: resolved, index: ix}, nil
+}
 
-	// Validate the instance
-	if err := resolved.Validate(instance); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+// prepare converts schema and runs the dialect and local reference checks,
+// returning a schema that is ready to resolve along with the index of its
+// JSON document.
+func (v *DefaultValidator) prepare(schema any) (*jsonschema.Schema, *schemaIndex, error) {
+	// Convert schema to jsonschema.Schema
+	jsSchema, err := v.toJSONSchema(schema)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	// Check $schema dialect
+	if err := v.checkDialect(jsSchema); err != nil {
+		return nil, nil, err
+	}
+
+	// Fail fast on dangling or endlessly cycling local references
+	root, err := schemaDocument(jsSchema)
+	if err != nil {
+		return nil, nil, err
+	}
+	ix := newSchemaIndex(root)
+	if err := checkInternalRefs(ix); err != nil {
+		return nil, nil, err
+	}
+	if err := checkRefCycles(ix); err != nil {
+		return nil, nil, err
+	}
+	if v.rejectExternalIDs {
+		if err := checkExternalIDs(root); err != nil {
+			return nil, nil, err
+		}
+	}
+	return jsSchema, ix, nil
+}
+
+// schemaDocument returns jsSchema as a fresh JSON object. jsonschema-go
+// encodes the empty schema as true and its negation as false; those become
+// {} and {"not": {}} so callers can always walk an object.
+func schemaDocument(jsSchema *jsonschema.Schema) (map[string]any, error) {
+	var doc any
+	if err := jsonRoundTrip(jsSchema, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	switch d := doc.(type) {
+	case map[string]any:
+		return d, nil
+	case bool:
+		if d {
+			return map[string]any{}, nil
+		}
+		return map[string]any{"not": map[string]any{}}, nil
+	}
+	return nil, fmt.Errorf("%w: schema is not a JSON object", ErrInvalidSchema)
 }
 
 // ValidateInput validates tool input arguments against the tool's InputSchema.
@@ -103,24 +499,388 @@ func (v *DefaultValidator) ValidateInput(tool *Tool, args any) error {
 	if tool == nil {
 		return fmt.Errorf("%w: tool is nil", ErrInvalidSchema)
 	}
-	if tool.InputSchema == nil {
+	if !schemaPresent(tool.InputSchema) {
 		return fmt.Errorf("%w: InputSchema is nil", ErrInvalidSchema)
 	}
 	return v.Validate(tool.InputSchema, args)
 }
 
+// ApplyDefaults returns a copy of args in which each property missing from
+// an object is filled in from the "default" of its subschema in the tool's
+// InputSchema. It recurses into nested objects, whether supplied by the
+// caller or just defaulted, following local "$ref"s; objects that are absent
+// and have no default are not created. Values the caller supplied are never
+// overwritten, and args itself is not modified. Defaults are copied as
+// decoded JSON, so numbers are float64. The result is not validated.
+func (v *DefaultValidator) ApplyDefaults(tool *Tool, args map[string]any) (map[string]any, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("%w: tool is nil", ErrInvalidSchema)
+	}
+	if !schemaPresent(tool.InputSchema) {
+		return nil, fmt.Errorf("%w: InputSchema is nil", ErrInvalidSchema)
+	}
+	out, _ := cloneJSONValue(args).(map[string]any)
+	if out == nil {
+		out = make(map[string]any)
+	}
+	if _, ok := booleanSchema(tool.InputSchema); ok {
+		return out, nil
+	}
+	_, ix, err := v.prepare(tool.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	applyDefaults(ix, "", out)
+	return out, nil
+}
+
+// applyDefaults fills the missing properties of obj from the defaults
+// declared by the subschema at path, recursing into nested objects.
+func applyDefaults(ix *schemaIndex, path string, obj map[string]any) {
+	path, schema := derefLocal(ix, path)
+	props := schemaProperties(schema)
+	for _, name := range sortedKeys(props) {
+		propPath := path + "/properties/" + escapeJSONPointer(name)
+		prop, ok := ix.nodes[propPath]
+		if !ok {
+			continue
+		}
+		targetPath, target := derefLocal(ix, propPath)
+		if _, present := obj[name]; !present {
+			// A "default" beside a "$ref" takes precedence over the target's.
+			def, ok := prop["default"]
+			if !ok {
+				def, ok = target["default"]
+			}
+			if !ok {
+				continue
+			}
+			obj[name] = cloneJSONValue(def)
+		}
+		if nested, ok := obj[name].(map[string]any); ok {
+			applyDefaults(ix, targetPath, nested)
+		}
+	}
+}
+
+// ValidateUpdate applies a partial update to a copy of current, setting value
+// at pointer (an RFC 6901 JSON Pointer such as "/filters/lang"), and validates
+// the merged arguments against the tool's InputSchema, since multi-step
+// collection may not have every field yet. Missing intermediate objects are
+// created and "-" appends to an array. The schema is used unchanged; only
+// failures reporting a missing property of an object are dropped, so
+// "required" inside "oneOf", "not" or "if" keeps its meaning. It returns the
+// merged arguments, or the validation error; current is never modified.
+func (v *DefaultValidator) ValidateUpdate(tool *Tool, current map[string]any, pointer string, value any) (map[string]any, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("%w: tool is nil", ErrInvalidSchema)
+	}
+	if !schemaPresent(tool.InputSchema) {
+		return nil, fmt.Errorf("%w: InputSchema is nil", ErrInvalidSchema)
+	}
+	merged, ok := cloneJSONValue(current).(map[string]any)
+	if !ok || merged == nil {
+		merged = map[string]any{}
+	}
+	root, err := setJSONPointer(merged, pointer, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkInstanceSize(root); err != nil {
+		return nil, err
+	}
+	violations, err := v.violations(tool.InputSchema, root)
+	if err != nil {
+		return nil, err
+	}
+	var remaining []violation
+	for _, vi := range violations {
+		if vi.keyword == "required" && strings.HasSuffix(vi.schemaPath, "/required") {
+			continue
+		}
+		remaining = append(remaining, vi)
+	}
+	if len(remaining) > 0 {
+		ve := newValidationError(remaining, nil)
+		ve.all = v.allErrors
+		return nil, ve
+	}
+	return root.(map[string]any), nil
+}
+
+// CoerceInput returns a copy of args in which string values are converted to
+// the type their InputSchema subschema declares, for arguments that arrive
+// from CLI flags or query strings: "10" becomes int64(10) for "integer",
+// "3.14" becomes 3.14 for "number", "true"/"false" become booleans, and a
+// JSON array such as `["a","b"]` is decoded for "array". It recurses into
+// nested object properties and array items, following local "$ref"s.
+// A value is converted only when the subschema allows exactly one type
+// besides "null" and that type is not "string"; ambiguous or failed
+// conversions leave the value as it was so validation can report it. args
+// itself is not modified, and the result is not validated.
+func (v *DefaultValidator) CoerceInput(tool *Tool, args map[string]any) (map[string]any, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("%w: tool is nil", ErrInvalidSchema)
+	}
+	if !schemaPresent(tool.InputSchema) {
+		return nil, fmt.Errorf("%w: InputSchema is nil", ErrInvalidSchema)
+	}
+	out, _ := cloneJSONValue(args).(map[string]any)
+	if out == nil {
+		out = make(map[string]any)
+	}
+	if _, ok := booleanSchema(tool.InputSchema); ok {
+		return out, nil
+	}
+	_, ix, err := v.prepare(tool.InputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return coerceValue(ix, "", out).(map[string]any), nil
+}
+
+// coerceValue converts value, and the members of objects and arrays, to the
+// types the subschema at path declares.
+func coerceValue(ix *schemaIndex, path string, value any) any {
+	path, schema := derefLocal(ix, path)
+	if s, ok := value.(string); ok {
+		value = coerceString(s, coercionTarget(schema))
+	}
+	switch val := value.(type) {
+	case map[string]any:
+		for name, member := range val {
+			propPath := path + "/properties/" + escapeJSONPointer(name)
+			if _, ok := ix.nodes[propPath]; ok {
+				val[name] = coerceValue(ix, propPath, member)
+			}
+		}
+	case []any:
+		if _, ok := ix.nodes[path+"/items"]; ok {
+			for i, item := range val {
+				val[i] = coerceValue(ix, path+"/items", item)
+			}
+		}
+	}
+	return value
+}
+
+// coercionTarget returns the single non-null type schema allows, or "" when
+// the type is absent or ambiguous.
+func coercionTarget(schema map[string]any) string {
+	target := ""
+	for _, typ := range schemaTypes(schema) {
+		if typ == "null" {
+			continue
+		}
+		if target != "" {
+			return ""
+		}
+		target = typ
+	}
+	return target
+}
+
+// coerceString converts s to target, returning s unchanged when it does not
+// parse as that type.
+func coerceString(s, target string) any {
+	switch target {
+	case "integer":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+	case "boolean":
+		switch s {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	case "array":
+		var list []any
+		if err := json.Unmarshal([]byte(s), &list); err == nil && list != nil {
+			return list
+		}
+	}
+	return s
+}
+
+// derefLocal follows a chain of local "$ref"s from the subschema at path to
+// the node they point at, returning that node and its path. A reference that
+// cannot be followed leaves the last node reached; prepare has already
+// rejected dangling and cyclic references.
+func derefLocal(ix *schemaIndex, path string) (string, map[string]any) {
+	for range maxRefChain {
+		ref, ok := ix.nodes[path]["$ref"].(string)
+		if !ok {
+			break
+		}
+		target, ok, err := ix.resolveLocalRef(path, ref)
+		if _, isNode := ix.nodes[target]; !ok || err != nil || !isNode {
+			break
+		}
+		path = target
+	}
+	return path, ix.nodes[path]
+}
+
+// maxRefChain bounds the number of "$ref"s derefLocal follows.
+const maxRefChain = 32
+
 // ValidateOutput validates tool output against the tool's OutputSchema if present.
 // Returns nil if OutputSchema is not defined.
 func (v *DefaultValidator) ValidateOutput(tool *Tool, result any) error {
 	if tool == nil {
 		return fmt.Errorf("%w: tool is nil", ErrInvalidSchema)
 	}
-	if tool.OutputSchema == nil {
+	if !tool.HasStructuredOutput() {
 		return nil // OutputSchema is optional
 	}
+	if v.sharedInputDefs && schemaPresent(tool.InputSchema) {
+		schema, err := withInputDefs(tool.OutputSchema, tool.InputSchema)
+		if err != nil {
+			return err
+		}
+		return v.Validate(schema, result)
+	}
 	return v.Validate(tool.OutputSchema, result)
 }
 
+// ValidateOutputOneOf validates result against a list of candidate output
+// schemas, for tools that return one of several result shapes. It succeeds
+// only when result validates against exactly one candidate; otherwise the
+// error summarizes why each candidate failed, or which candidates matched.
+// A candidate that is not a valid schema fails the whole call.
+//
+// Each candidate is checked with Validate, so the validator's options (such
+// as WithFormatAssertion) apply. A mismatch is reported as a *ValidationError
+// with keyword "oneOf", as if the candidates were the branches of a "oneOf"
+// at "/oneOf"; when none matches, Errors also lists each candidate's failures
+// under "/oneOf/<index>".
+func (v *DefaultValidator) ValidateOutputOneOf(schemas []any, result any) error {
+	if len(schemas) == 0 {
+		return fmt.Errorf("%w: no candidate output schemas", ErrInvalidSchema)
+	}
+	if err := v.checkInstanceSize(result); err != nil {
+		return err
+	}
+	var matched, failures []string
+	var branches []violation
+	for i, schema := range schemas {
+		err := v.Validate(schema, result)
+		if err == nil {
+			matched = append(matched, fmt.Sprintf("schemas[%d]", i))
+			continue
+		}
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			return fmt.Errorf("schemas[%d]: %w", i, err)
+		}
+		failures = append(failures, fmt.Sprintf("schemas[%d]: %v", i, ve.violation()))
+		entries := ve.Errors
+		if len(entries) == 0 {
+			entries = []ValidationError{*ve}
+		}
+		for _, e := range entries {
+			vi := e.violation()
+			vi.schemaPath = "/oneOf/" + strconv.Itoa(i) + vi.schemaPath
+			branches = append(branches, vi)
+		}
+	}
+	top := violation{schemaPath: "/oneOf", keyword: "oneOf"}
+	switch len(matched) {
+	case 1:
+		return nil
+	case 0:
+		top.message = fmt.Sprintf("result matches none of %d output schemas: %s", len(schemas), strings.Join(failures, "; "))
+	default:
+		top.message = fmt.Sprintf("result matches %s, want exactly one", strings.Join(matched, ", "))
+		branches = nil // the failing candidates are not the problem
+	}
+	ve := newValidationError(append([]violation{top}, branches...), nil)
+	ve.all = v.allErrors
+	return ve
+}
+
+// withInputDefs returns a copy of output extended with the definitions of
+// input that output does not declare itself.
+func withInputDefs(output, input any) (map[string]any, error) {
+	out, err := schemaToMap(output)
+	if err != nil {
+		return nil, err
+	}
+	in, err := schemaToMap(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, kw := range []string{"$defs", "definitions"} {
+		shared, ok := in[kw].(map[string]any)
+		if !ok || len(shared) == 0 {
+			continue
+		}
+		own, ok := out[kw].(map[string]any)
+		if !ok {
+			own = make(map[string]any, len(shared))
+			out[kw] = own
+		}
+		for name, def := range shared {
+			if _, exists := own[name]; !exists {
+				own[name] = def
+			}
+		}
+	}
+	return out, nil
+}
+
+// ValidateInputWithEnums validates args against the tool's InputSchema after
+// constraining each named top-level property to the values in enums. This lets
+// callers inject dynamic choices (e.g. available project IDs) at validation
+// time. The injected "enum" is combined with the property's existing
+// constraints; the tool's schema is not modified.
+func (v *DefaultValidator) ValidateInputWithEnums(tool *Tool, args any, enums map[string][]any) error {
+	if tool == nil {
+		return fmt.Errorf("%w: tool is nil", ErrInvalidSchema)
+	}
+	if !schemaPresent(tool.InputSchema) {
+		return fmt.Errorf("%w: InputSchema is nil", ErrInvalidSchema)
+	}
+	schema, err := schemaToMap(tool.InputSchema)
+	if err != nil {
+		return err
+	}
+	props := schemaProperties(schema)
+	if props == nil {
+		props = make(map[string]any, len(enums))
+		schema["properties"] = props
+	}
+	for name, values := range enums {
+		var enum []any
+		if err := jsonRoundTrip(values, &enum); err != nil {
+			return fmt.Errorf("%w: enum for %q: %v", ErrInvalidSchema, name, err)
+		}
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			prop = map[string]any{}
+		}
+		if existing, ok := prop["enum"]; ok {
+			// Keep the schema's own enum as an additional constraint.
+			prop["allOf"] = append(toAnySlice(prop["allOf"]), map[string]any{"enum": existing})
+		}
+		prop["enum"] = enum
+		props[name] = prop
+	}
+	return v.Validate(schema, args)
+}
+
+// toAnySlice returns v as a []any, or nil if it is not one.
+func toAnySlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
 // toJSONSchema converts various schema representations to jsonschema.Schema.
 func (v *DefaultValidator) toJSONSchema(schema any) (*jsonschema.Schema, error) {
 	switch s := schema.(type) {
@@ -2,7 +2,10 @@ package toolmodel
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/jsonschema-go/jsonschema"
@@ -127,6 +130,111 @@ func TestDefaultValidator_Validate_ObjectProperties(t *testing.T) {
 	}
 }
 
+func TestDefaultValidator_Validate_RequiredOrder(t *testing.T) {
+	v := NewDefaultValidator()
+
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"zip":     map[string]any{"type": "string"},
+			"city":    map[string]any{"type": "string"},
+			"address": map[string]any{"type": "string"},
+		},
+		"required": []any{"zip", "city", "address"},
+	}
+
+	err := v.Validate(schema, map[string]any{})
+	if err == nil {
+		t.Fatal("Validate() expected error for missing required fields")
+	}
+	want := `missing properties: ["zip" "city" "address"]`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("Validate() error = %q, want it to contain %q", err.Error(), want)
+	}
+}
+
+func TestDefaultValidator_Validate_Conditionals(t *testing.T) {
+	v := NewDefaultValidator()
+
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"country": map[string]any{"type": "string"},
+			"zip":     map[string]any{"type": "string"},
+			"postal":  map[string]any{"type": "string"},
+		},
+		"if": map[string]any{
+			"properties": map[string]any{"country": map[string]any{"const": "US"}},
+			"required":   []any{"country"},
+		},
+		"then": map[string]any{"required": []any{"zip"}},
+		"else": map[string]any{"required": []any{"postal"}},
+		"dependentRequired": map[string]any{
+			"zip": []any{"country"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schema   any
+		instance any
+		wantErr  bool
+	}{
+		{
+			name:     "then branch satisfied",
+			schema:   schema,
+			instance: map[string]any{"country": "US", "zip": "94103"},
+		},
+		{
+			name:     "then branch violated",
+			schema:   schema,
+			instance: map[string]any{"country": "US"},
+			wantErr:  true,
+		},
+		{
+			name:     "else branch satisfied",
+			schema:   schema,
+			instance: map[string]any{"country": "FR", "postal": "75001"},
+		},
+		{
+			name:     "else branch violated",
+			schema:   schema,
+			instance: map[string]any{"country": "FR", "zip": "75001"},
+			wantErr:  true,
+		},
+		{
+			name:     "dependentRequired violated",
+			schema:   schema,
+			instance: map[string]any{"zip": "94103", "postal": "x"},
+			wantErr:  true,
+		},
+		{
+			name:     "raw message schema then branch violated",
+			schema:   mustMarshal(t, schema),
+			instance: map[string]any{"country": "US"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.schema, tt.instance)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
+
 func TestDefaultValidator_Validate_NoParameters(t *testing.T) {
 	v := NewDefaultValidator()
 
@@ -423,6 +531,51 @@ func TestDefaultValidator_ValidateInput_NilSchema(t *testing.T) {
 	}
 }
 
+func TestDefaultValidator_ValidateInput_JsonschemaSchema(t *testing.T) {
+	v := NewDefaultValidator()
+
+	tool := Tool{
+		Tool: mcp.Tool{
+			Name: "typed-schema-tool",
+			InputSchema: &jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"query": {Type: "string"},
+				},
+				Required: []string{"query"},
+			},
+		},
+	}
+	if err := tool.Validate(); err != nil {
+		t.Fatalf("Tool.Validate() unexpected error: %v", err)
+	}
+	if err := v.ValidateInput(&tool, map[string]any{"query": "go"}); err != nil {
+		t.Errorf("ValidateInput() unexpected error: %v", err)
+	}
+	if err := v.ValidateInput(&tool, map[string]any{"query": 1}); err == nil {
+		t.Error("ValidateInput() expected error for wrong property type")
+	}
+
+	t.Run("typed nil schema", func(t *testing.T) {
+		nilTool := Tool{
+			Tool: mcp.Tool{
+				Name:         "typed-nil-tool",
+				InputSchema:  (*jsonschema.Schema)(nil),
+				OutputSchema: (*jsonschema.Schema)(nil),
+			},
+		}
+		if err := nilTool.Validate(); !errors.Is(err, ErrInvalidTool) {
+			t.Errorf("Tool.Validate() error = %v, want ErrInvalidTool", err)
+		}
+		if err := v.ValidateInput(&nilTool, map[string]any{}); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("ValidateInput() error = %v, want ErrInvalidSchema", err)
+		}
+		if err := v.ValidateOutput(&nilTool, map[string]any{}); err != nil {
+			t.Errorf("ValidateOutput() error = %v, want nil for absent OutputSchema", err)
+		}
+	})
+}
+
 func TestDefaultValidator_ValidateOutput(t *testing.T) {
 	v := NewDefaultValidator()
 
@@ -472,6 +625,80 @@ func TestDefaultValidator_ValidateOutput(t *testing.T) {
 	})
 }
 
+func TestDefaultValidator_ValidateOutputOneOf(t *testing.T) {
+	page := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"items": map[string]any{"type": "array"}},
+		"required":   []any{"items"},
+	}
+	failure := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"error": map[string]any{"type": "string"}},
+		"required":   []any{"error"},
+	}
+	anyObject := map[string]any{"type": "object"}
+
+	v := NewDefaultValidator()
+	tests := []struct {
+		name    string
+		schemas []any
+		result  any
+		wantErr string
+	}{
+		{name: "exactly one", schemas: []any{page, failure}, result: map[string]any{"items": []any{}}},
+		{name: "none", schemas: []any{page, failure}, result: map[string]any{"count": 1}, wantErr: "matches none of 2 output schemas"},
+		{name: "two", schemas: []any{page, anyObject}, result: map[string]any{"items": []any{}}, wantErr: "matches schemas[0], schemas[1], want exactly one"},
+		{name: "no candidates", result: map[string]any{}, wantErr: ErrInvalidSchema.Error()},
+		{name: "invalid candidate", schemas: []any{page, "bogus"}, result: map[string]any{}, wantErr: "schemas[1]: " + ErrInvalidSchema.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateOutputOneOf(tt.schemas, tt.result)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateOutputOneOf() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateOutputOneOf() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("structured failure", func(t *testing.T) {
+		err := v.ValidateOutputOneOf([]any{page, failure}, map[string]any{"count": 1})
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Keyword != "oneOf" || ve.SchemaPath != "/oneOf" {
+			t.Fatalf("ValidateOutputOneOf() error = %#v, want oneOf *ValidationError", err)
+		}
+		var paths []string
+		for _, e := range ve.Errors[1:] {
+			paths = append(paths, e.SchemaPath)
+		}
+		if want := []string{"/oneOf/0/required", "/oneOf/1/required"}; !reflect.DeepEqual(paths, want) {
+			t.Errorf("candidate failures at %v, want %v", paths, want)
+		}
+	})
+
+	t.Run("format assertion", func(t *testing.T) {
+		contact := map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"e": map[string]any{"type": "string", "format": "email"}},
+			"required":   []any{"e"},
+		}
+		result := map[string]any{"e": "nope"}
+		if err := v.ValidateOutputOneOf([]any{contact}, result); err != nil {
+			t.Errorf("ValidateOutputOneOf() without assertion error = %v", err)
+		}
+		strict := NewDefaultValidator(WithFormatAssertion(true))
+		var ve *ValidationError
+		if err := strict.ValidateOutputOneOf([]any{contact}, result); !errors.As(err, &ve) || !strings.Contains(ve.Message, "not a valid email") {
+			t.Errorf("ValidateOutputOneOf() with assertion error = %v, want email failure", err)
+		}
+	})
+}
+
 func TestDefaultValidator_ExternalRefBlocked(t *testing.T) {
 	v := NewDefaultValidator()
 
@@ -528,3 +755,878 @@ func TestSchemaValidator_Interface(t *testing.T) {
 	var _ SchemaValidator = (*DefaultValidator)(nil)
 	var _ SchemaValidator = NewDefaultValidator()
 }
+
+func TestDefaultValidator_ValidateInputWithEnums(t *testing.T) {
+	v := NewDefaultValidator()
+
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"project": map[string]any{"type": "string"},
+			"env":     map[string]any{"type": "string", "enum": []any{"dev", "prod"}},
+		},
+		"required": []any{"project"},
+	}
+	tool := Tool{Tool: mcp.Tool{Name: "deploy", InputSchema: schema}}
+	enums := map[string][]any{
+		"project": {"alpha", "beta"},
+		"env":     {"prod", "staging"},
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr bool
+	}{
+		{name: "inside injected enum", args: map[string]any{"project": "alpha"}},
+		{name: "outside injected enum", args: map[string]any{"project": "gamma"}, wantErr: true},
+		{name: "in both enums", args: map[string]any{"project": "beta", "env": "prod"}},
+		{name: "only in schema enum", args: map[string]any{"project": "beta", "env": "dev"}, wantErr: true},
+		{name: "only in injected enum", args: map[string]any{"project": "beta", "env": "staging"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateInputWithEnums(&tool, tt.args, enums)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInputWithEnums() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	project := schema["properties"].(map[string]any)["project"].(map[string]any)
+	if _, ok := project["enum"]; ok {
+		t.Error("ValidateInputWithEnums() mutated the tool's schema")
+	}
+	if err := v.ValidateInput(&tool, map[string]any{"project": "gamma"}); err != nil {
+		t.Errorf("ValidateInput() without enums error = %v, want nil", err)
+	}
+}
+
+func TestValidateSchema_InternalRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  any
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name: "valid defs ref",
+			schema: map[string]any{
+				"$defs": map[string]any{"id": map[string]any{"type": "string"}},
+				"type":  "object",
+				"properties": map[string]any{
+					"id": map[string]any{"$ref": "#/$defs/id"},
+				},
+			},
+		},
+		{
+			name: "dangling defs ref",
+			schema: map[string]any{
+				"$defs": map[string]any{"id": map[string]any{"type": "string"}},
+				"type":  "object",
+				"properties": map[string]any{
+					"owner": map[string]any{"$ref": "#/$defs/user"},
+				},
+			},
+			wantErr: ErrInvalidSchema,
+			wantMsg: "#/$defs/user",
+		},
+		{
+			name:   "root ref",
+			schema: json.RawMessage(`{"type":"object","properties":{"child":{"$ref":"#"}}}`),
+		},
+		{
+			name:   "anchor ref",
+			schema: json.RawMessage(`{"$defs":{"n":{"$anchor":"node","type":"string"}},"$ref":"#node"}`),
+		},
+		{
+			name:    "dangling anchor ref",
+			schema:  json.RawMessage(`{"$ref":"#missing"}`),
+			wantErr: ErrInvalidSchema,
+			wantMsg: "#missing",
+		},
+		{
+			name:    "external ref",
+			schema:  map[string]any{"$ref": "https://example.com/schema.json"},
+			wantErr: ErrExternalRef,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(tt.schema)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateSchema() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateSchema() error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("ValidateSchema() error = %q, want it to name %q", err, tt.wantMsg)
+			}
+		})
+	}
+
+	t.Run("Validate fails fast on dangling ref", func(t *testing.T) {
+		err := NewDefaultValidator().Validate(map[string]any{"$ref": "#/$defs/nope"}, "x")
+		if !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("Validate() error = %v, want ErrInvalidSchema", err)
+		}
+	})
+}
+
+func TestDefaultValidator_FirstError(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"items": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"name": map[string]any{"type": "string"}},
+				},
+			},
+		},
+	}
+	v := NewDefaultValidator()
+
+	t.Run("nested property", func(t *testing.T) {
+		instance := map[string]any{"items": []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b"},
+			map[string]any{"name": 3},
+			map[string]any{"name": 4},
+		}}
+		pointer, keyword, err := v.FirstError(schema, instance)
+		if err == nil {
+			t.Fatal("FirstError() error = nil, want failure")
+		}
+		if pointer != "/items/2/name" || keyword != "type" {
+			t.Errorf("FirstError() = (%q, %q), want (/items/2/name, type)", pointer, keyword)
+		}
+		if !strings.Contains(err.Error(), "/items/2/name") {
+			t.Errorf("FirstError() error = %v, want pointer in message", err)
+		}
+	})
+
+	t.Run("valid instance", func(t *testing.T) {
+		pointer, keyword, err := v.FirstError(schema, map[string]any{"items": []any{}})
+		if pointer != "" || keyword != "" || err != nil {
+			t.Errorf("FirstError() = (%q, %q, %v), want empty", pointer, keyword, err)
+		}
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, _, err := v.FirstError(map[string]any{"$ref": "#/$defs/missing"}, 1)
+		if !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("FirstError() error = %v, want ErrInvalidSchema", err)
+		}
+	})
+}
+
+func TestDefaultValidator_WithMaxInstanceBytes(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	v := NewDefaultValidator(WithMaxInstanceBytes(32))
+
+	small := map[string]any{"ok": true}
+	large := map[string]any{"data": strings.Repeat("x", 64)}
+
+	if err := v.Validate(schema, small); err != nil {
+		t.Errorf("Validate(small) error = %v", err)
+	}
+	if err := v.Validate(schema, large); !errors.Is(err, ErrInstanceTooLarge) {
+		t.Errorf("Validate(large) error = %v, want ErrInstanceTooLarge", err)
+	}
+	tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema, OutputSchema: schema}}
+	if err := v.ValidateOutput(tool, large); !errors.Is(err, ErrInstanceTooLarge) {
+		t.Errorf("ValidateOutput(large) error = %v, want ErrInstanceTooLarge", err)
+	}
+	if err := NewDefaultValidator().Validate(schema, large); err != nil {
+		t.Errorf("Validate(large) without limit error = %v", err)
+	}
+}
+
+func TestDefaultValidator_WithRejectExternalIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		wantErr bool
+	}{
+		{
+			name: "external root $id",
+			schema: map[string]any{
+				"$id":        "https://example.com/schemas/args.json",
+				"type":       "object",
+				"properties": map[string]any{"user": map[string]any{"$ref": "user.json"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "external nested $id",
+			schema: map[string]any{
+				"type": "object",
+				"$defs": map[string]any{
+					"user": map[string]any{"$id": "http://example.com/user", "type": "string"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "internal nested $id",
+			schema: map[string]any{
+				"$id":        "urn:example:args",
+				"type":       "object",
+				"properties": map[string]any{"n": map[string]any{"$ref": "urn:example:count"}},
+				"$defs": map[string]any{
+					"count": map[string]any{"$id": "urn:example:count", "type": "integer"},
+				},
+			},
+		},
+		{
+			name:   "urn $id",
+			schema: map[string]any{"$id": "urn:example:args", "type": "object"},
+		},
+	}
+	v := NewDefaultValidator(WithRejectExternalIDs())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.schema, map[string]any{})
+			if tt.wantErr {
+				if !errors.Is(err, ErrExternalRef) {
+					t.Errorf("Validate() error = %v, want ErrExternalRef", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+
+	external := map[string]any{"$id": "https://example.com/args.json", "type": "object"}
+	if err := NewDefaultValidator().Validate(external, map[string]any{}); err != nil {
+		t.Errorf("Validate() without option error = %v", err)
+	}
+}
+
+func TestDefaultValidator_ValidateIgnoring(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"code":    map[string]any{"type": "string", "pattern": "^[A-Z]{3}$"},
+			"pattern": map[string]any{"type": "string"},
+			"tags": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string", "pattern": "^#"},
+			},
+		},
+		"required": []any{"pattern"},
+	}
+	instance := map[string]any{"code": "usd", "pattern": "x", "tags": []any{"go"}}
+
+	v := NewDefaultValidator()
+	if err := v.Validate(schema, instance); err == nil {
+		t.Error("Validate() error = nil, want pattern failure")
+	}
+	if err := v.ValidateIgnoring(schema, instance, "pattern"); err != nil {
+		t.Errorf("ValidateIgnoring(pattern) error = %v", err)
+	}
+	if err := v.ValidateIgnoring(schema, map[string]any{"code": 1, "pattern": "x"}, "pattern"); err == nil {
+		t.Error("ValidateIgnoring(pattern) accepted a type mismatch")
+	}
+	if err := v.ValidateIgnoring(schema, map[string]any{"code": "USD"}, "pattern"); err == nil {
+		t.Error(`ValidateIgnoring(pattern) dropped the property named "pattern" from required`)
+	}
+
+	props := schema["properties"].(map[string]any)
+	if _, ok := props["code"].(map[string]any)["pattern"]; !ok {
+		t.Error("ValidateIgnoring() modified the caller's schema")
+	}
+}
+
+func TestDefaultValidator_WithFormatAssertion(t *testing.T) {
+	tests := []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{"email", []string{"ada@example.com"}, []string{"not-an-email", "Ada <ada@example.com>"}},
+		{"uri", []string{"https://example.com/a?b=c", "urn:isbn:0451450523"}, []string{"/relative/path", "example.com"}},
+		{"date-time", []string{"2024-05-01T12:30:00Z", "2024-05-01T12:30:00.5+02:00"}, []string{"2024-05-01", "yesterday"}},
+		{"ipv4", []string{"192.168.0.1"}, []string{"256.0.0.1", "::1"}},
+		{"ipv6", []string{"::1", "2001:db8::8a2e:370:7334"}, []string{"192.168.0.1", "fe80::1%eth0"}},
+		{"uuid", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567e89b12d3a456426614174000"}},
+		{"hostname", []string{"example.com", "localhost"}, []string{"-bad.example.com", "under_score.com"}},
+	}
+	strict := NewDefaultValidator(WithFormatAssertion(true))
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			schema := map[string]any{"type": "string", "format": tt.format}
+			for _, s := range tt.valid {
+				if err := strict.Validate(schema, s); err != nil {
+					t.Errorf("Validate(%q) error = %v", s, err)
+				}
+			}
+			for _, s := range tt.invalid {
+				err := strict.Validate(schema, s)
+				var ve *ValidationError
+				if !errors.As(err, &ve) || ve.Keyword != "format" {
+					t.Errorf("Validate(%q) error = %v, want format failure", s, err)
+				}
+				if err := NewDefaultValidator().Validate(schema, s); err != nil {
+					t.Errorf("Validate(%q) without assertion error = %v", s, err)
+				}
+			}
+		})
+	}
+
+	t.Run("anyOf, oneOf and not", func(t *testing.T) {
+		email := map[string]any{"type": "string", "format": "email"}
+		tests := []struct {
+			name        string
+			schema      map[string]any
+			instance    string
+			wantKeyword string
+		}{
+			{name: "anyOf bad format", schema: map[string]any{"anyOf": []any{email}}, instance: "nope", wantKeyword: "format"},
+			{name: "anyOf good format", schema: map[string]any{"anyOf": []any{email}}, instance: "ada@example.com"},
+			{
+				name:     "anyOf other branch",
+				schema:   map[string]any{"anyOf": []any{email, map[string]any{"format": "uri"}}},
+				instance: "https://example.com",
+			},
+			{
+				name:     "oneOf format rules a branch out",
+				schema:   map[string]any{"oneOf": []any{email, map[string]any{"type": "string", "maxLength": 10}}},
+				instance: "nope",
+			},
+			{
+				name:        "oneOf both match",
+				schema:      map[string]any{"oneOf": []any{email, map[string]any{"type": "string", "maxLength": 20}}},
+				instance:    "ada@example.com",
+				wantKeyword: "oneOf",
+			},
+			{name: "not bad format", schema: map[string]any{"not": email}, instance: "nope"},
+			{name: "not good format", schema: map[string]any{"not": email}, instance: "ada@example.com", wantKeyword: "not"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := strict.Validate(tt.schema, tt.instance)
+				if tt.wantKeyword == "" {
+					if err != nil {
+						t.Errorf("Validate(%q) error = %v", tt.instance, err)
+					}
+					return
+				}
+				var ve *ValidationError
+				if !errors.As(err, &ve) || ve.Keyword != tt.wantKeyword {
+					t.Errorf("Validate(%q) error = %v, want %s failure", tt.instance, err, tt.wantKeyword)
+				}
+			})
+		}
+	})
+
+	t.Run("empty schema", func(t *testing.T) {
+		if err := strict.Validate(map[string]any{}, "anything"); err != nil {
+			t.Errorf("Validate({}) error = %v", err)
+		}
+	})
+
+	t.Run("nested and unknown formats", func(t *testing.T) {
+		schema := map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"owner":   map[string]any{"$ref": "#/$defs/email"},
+				"mirrors": map[string]any{"type": "array", "items": map[string]any{"type": "string", "format": "uri"}},
+				"version": map[string]any{"type": "string", "format": "semver"},
+				"port":    map[string]any{"type": "integer", "format": "email"},
+			},
+			"$defs": map[string]any{"email": map[string]any{"type": "string", "format": "email"}},
+		}
+		instance := map[string]any{
+			"owner":   "nobody",
+			"mirrors": []any{"https://a.example", "b.example"},
+			"version": "not checked",
+			"port":    8080,
+		}
+		var got []string
+		for _, err := range NewDefaultValidator(WithFormatAssertion(true)).ValidateAll(schema, instance) {
+			var ve *ValidationError
+			if errors.As(err, &ve) {
+				got = append(got, ve.InstancePath+" "+ve.Keyword)
+			}
+		}
+		if want := []string{"/mirrors/1 format", "/owner format"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("ValidateAll() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("alongside other failures", func(t *testing.T) {
+		schema := map[string]any{
+			"type":       "object",
+			"required":   []any{"id"},
+			"properties": map[string]any{"email": map[string]any{"type": "string", "format": "email"}},
+		}
+		tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema}}
+		err := strict.ValidateInputOpt(tool, map[string]any{"email": "x"}, WithAllErrors())
+		if err == nil || !strings.Contains(err.Error(), "missing property") || !strings.Contains(err.Error(), "not a valid email") {
+			t.Errorf("ValidateInputOpt() error = %v, want required and format failures", err)
+		}
+	})
+}
+
+func TestDefaultValidator_WithCustomFormat(t *testing.T) {
+	checkCron := func(s string) error {
+		if len(strings.Fields(s)) != 5 {
+			return errors.New("want 5 fields")
+		}
+		return nil
+	}
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"schedule": map[string]any{"type": "string", "format": "cron"},
+			"version":  map[string]any{"type": "string", "format": "semver"},
+		},
+	}
+	strict := NewDefaultValidator(WithFormatAssertion(true), WithCustomFormat("cron", checkCron))
+
+	tests := []struct {
+		name     string
+		v        *DefaultValidator
+		instance map[string]any
+		wantErr  bool
+	}{
+		{"valid", strict, map[string]any{"schedule": "0 * * * *"}, false},
+		{"invalid", strict, map[string]any{"schedule": "hourly"}, true},
+		{"unregistered format is annotation", strict, map[string]any{"version": "anything"}, false},
+		{"assertion off", NewDefaultValidator(WithCustomFormat("cron", checkCron)), map[string]any{"schedule": "hourly"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.v.Validate(schema, tt.instance)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) || ve.Keyword != "format" || ve.InstancePath != "/schedule" {
+				t.Fatalf("Validate() error = %v, want format failure at /schedule", err)
+			}
+			if !strings.Contains(ve.Message, "want 5 fields") {
+				t.Errorf("Message = %q, want checker error", ve.Message)
+			}
+		})
+	}
+
+	t.Run("overrides standard format", func(t *testing.T) {
+		v := NewDefaultValidator(WithFormatAssertion(true), WithCustomFormat("email", func(string) error { return nil }))
+		if err := v.Validate(map[string]any{"type": "string", "format": "email"}, "not-an-email"); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+	})
+
+	t.Run("per-call registration does not leak", func(t *testing.T) {
+		base := NewDefaultValidator(WithFormatAssertion(true))
+		tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema}}
+		args := map[string]any{"schedule": "hourly"}
+		if err := base.ValidateInputOpt(tool, args, WithCustomFormat("cron", checkCron)); err == nil {
+			t.Error("ValidateInputOpt() error = nil, want format failure")
+		}
+		if err := base.ValidateInput(tool, args); err != nil {
+			t.Errorf("ValidateInput() error = %v", err)
+		}
+	})
+}
+
+func TestDefaultValidator_WithSchemaCacheSize(t *testing.T) {
+	newSchema := func(max int) map[string]any {
+		return map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"n": map[string]any{"type": "integer", "maximum": max}},
+		}
+	}
+	v := NewDefaultValidator(WithSchemaCacheSize(2))
+
+	first, err := v.resolve(newSchema(10))
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	again, err := v.resolve(json.RawMessage(`{"properties":{"n":{"maximum":10,"type":"integer"}},"type":"object"}`))
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if again != first {
+		t.Error("resolve() recompiled an identical schema")
+	}
+	if first.index == nil || first.index.nodes["/properties/n"] == nil {
+		t.Error("resolve() did not cache the schema index")
+	}
+	if err := v.Validate(newSchema(10), map[string]any{"n": 11}); err == nil {
+		t.Error("Validate() with cached schema error = nil, want failure")
+	}
+
+	if _, err := v.resolve(map[string]any{"type": "object", "properties": "bad"}); err == nil {
+		t.Error("resolve(invalid) error = nil")
+	}
+	for _, max := range []int{20, 30} {
+		if err := v.Validate(newSchema(max), map[string]any{"n": 1}); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+	}
+	if got := v.cache.len(); got != 2 {
+		t.Errorf("cache holds %d schemas, want 2", got)
+	}
+	if evicted, _ := v.resolve(newSchema(10)); evicted == first {
+		t.Error("resolve() returned an entry that should have been evicted")
+	}
+
+	t.Run("options are part of the key", func(t *testing.T) {
+		schema := map[string]any{"$id": "https://example.com/s", "type": "object"}
+		if err := v.Validate(schema, map[string]any{}); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema}}
+		if err := v.ValidateInputOpt(tool, map[string]any{}, WithRejectExternalIDs()); !errors.Is(err, ErrExternalRef) {
+			t.Errorf("ValidateInputOpt() error = %v, want ErrExternalRef", err)
+		}
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 50 {
+					_ = v.Validate(newSchema((i+j)%4), map[string]any{"n": j})
+				}
+			}()
+		}
+		wg.Wait()
+		if got := v.cache.len(); got > 2 {
+			t.Errorf("cache holds %d schemas, want at most 2", got)
+		}
+	})
+
+	if NewDefaultValidator(WithSchemaCacheSize(0)).cache != nil {
+		t.Error("WithSchemaCacheSize(0) enabled the cache")
+	}
+}
+
+func TestDefaultValidator_ApplyDefaults(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{"type": "string"},
+			"limit": map[string]any{"type": "integer", "default": 10},
+			"filters": map[string]any{
+				"type":    "object",
+				"default": map[string]any{},
+				"properties": map[string]any{
+					"lang": map[string]any{"type": "string", "default": "en"},
+					"safe": map[string]any{"type": "boolean", "default": true},
+				},
+			},
+			"paging": map[string]any{"$ref": "#/$defs/paging"},
+		},
+		"$defs": map[string]any{
+			"paging": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"size": map[string]any{"type": "integer", "default": 20}},
+			},
+		},
+	}}}
+	v := NewDefaultValidator()
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "fills missing",
+			args: map[string]any{"query": "go"},
+			want: map[string]any{
+				"query":   "go",
+				"limit":   float64(10),
+				"filters": map[string]any{"lang": "en", "safe": true},
+			},
+		},
+		{
+			name: "keeps supplied values",
+			args: map[string]any{"limit": 5, "filters": map[string]any{"safe": false}, "paging": map[string]any{}},
+			want: map[string]any{
+				"limit":   5,
+				"filters": map[string]any{"lang": "en", "safe": false},
+				"paging":  map[string]any{"size": float64(20)},
+			},
+		},
+		{
+			name: "nil args",
+			args: nil,
+			want: map[string]any{"limit": float64(10), "filters": map[string]any{"lang": "en", "safe": true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.ApplyDefaults(tool, tt.args)
+			if err != nil {
+				t.Fatalf("ApplyDefaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyDefaults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	args := map[string]any{"filters": map[string]any{}}
+	if _, err := v.ApplyDefaults(tool, args); err != nil {
+		t.Fatalf("ApplyDefaults() error = %v", err)
+	}
+	if want := (map[string]any{"filters": map[string]any{}}); !reflect.DeepEqual(args, want) {
+		t.Errorf("ApplyDefaults() modified args: %v", args)
+	}
+	filled, _ := v.ApplyDefaults(tool, nil)
+	filled["filters"].(map[string]any)["lang"] = "fr"
+	if again, _ := v.ApplyDefaults(tool, nil); again["filters"].(map[string]any)["lang"] != "en" {
+		t.Error("ApplyDefaults() result aliases the schema's defaults")
+	}
+
+	if _, err := v.ApplyDefaults(nil, nil); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ApplyDefaults(nil) error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestDefaultValidator_CoerceInput(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{Name: "search", InputSchema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"limit":  map[string]any{"type": "integer"},
+			"score":  map[string]any{"type": "number"},
+			"exact":  map[string]any{"type": "boolean"},
+			"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"ids":    map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+			"query":  map[string]any{"type": "string"},
+			"either": map[string]any{"type": []any{"integer", "string"}},
+			"opt":    map[string]any{"type": []any{"integer", "null"}},
+			"page":   map[string]any{"$ref": "#/$defs/page"},
+		},
+		"$defs": map[string]any{
+			"page": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"size": map[string]any{"type": "integer"}},
+			},
+		},
+	}}}
+	v := NewDefaultValidator()
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "scalars",
+			args: map[string]any{"limit": "10", "score": "3.14", "exact": "true", "opt": "7"},
+			want: map[string]any{"limit": int64(10), "score": 3.14, "exact": true, "opt": int64(7)},
+		},
+		{
+			name: "arrays",
+			args: map[string]any{"tags": `["a","b"]`, "ids": []any{"1", "2"}},
+			want: map[string]any{"tags": []any{"a", "b"}, "ids": []any{int64(1), int64(2)}},
+		},
+		{
+			name: "nested object through ref",
+			args: map[string]any{"page": map[string]any{"size": "20"}},
+			want: map[string]any{"page": map[string]any{"size": int64(20)}},
+		},
+		{
+			name: "ambiguous and string targets untouched",
+			args: map[string]any{"either": "10", "query": "10", "extra": "10"},
+			want: map[string]any{"either": "10", "query": "10", "extra": "10"},
+		},
+		{
+			name: "failed conversions untouched",
+			args: map[string]any{"limit": "ten", "score": "1.5.2", "exact": "yes", "tags": "a,b", "ids": "1.5"},
+			want: map[string]any{"limit": "ten", "score": "1.5.2", "exact": "yes", "tags": "a,b", "ids": "1.5"},
+		},
+		{
+			name: "typed values untouched",
+			args: map[string]any{"limit": 5, "exact": false},
+			want: map[string]any{"limit": 5, "exact": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.CoerceInput(tool, tt.args)
+			if err != nil {
+				t.Fatalf("CoerceInput() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CoerceInput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	args := map[string]any{"limit": "10", "page": map[string]any{"size": "20"}}
+	coerced, err := v.CoerceInput(tool, args)
+	if err != nil {
+		t.Fatalf("CoerceInput() error = %v", err)
+	}
+	if err := v.ValidateInput(tool, coerced); err != nil {
+		t.Errorf("ValidateInput(coerced) error = %v", err)
+	}
+	if want := (map[string]any{"limit": "10", "page": map[string]any{"size": "20"}}); !reflect.DeepEqual(args, want) {
+		t.Errorf("CoerceInput() modified args: %v", args)
+	}
+	if _, err := v.CoerceInput(nil, args); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("CoerceInput(nil) error = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestDefaultValidator_ValidateReader(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"n": map[string]any{"type": "integer"}},
+	}
+
+	tests := []struct {
+		name    string
+		v       *DefaultValidator
+		input   string
+		wantErr error
+		invalid bool
+	}{
+		{"valid", NewDefaultValidator(), `{"n": 1}`, nil, false},
+		{"schema failure", NewDefaultValidator(), `{"n": "x"}`, nil, true},
+		{"malformed JSON", NewDefaultValidator(), `{"n":`, nil, true},
+		{"within limit", NewDefaultValidator(WithMaxInstanceBytes(8)), `{"n": 1}`, nil, false},
+		{"over limit", NewDefaultValidator(WithMaxInstanceBytes(8)), `{"n": 12345}`, ErrInstanceTooLarge, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.v.ValidateReader(schema, strings.NewReader(tt.input))
+			if (err != nil) != tt.invalid {
+				t.Fatalf("ValidateReader() error = %v, want error %v", err, tt.invalid)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateReader() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_RecursiveSchema(t *testing.T) {
+	tree := map[string]any{
+		"$ref": "#/$defs/comment",
+		"$defs": map[string]any{
+			"comment": map[string]any{
+				"type":     "object",
+				"required": []any{"text"},
+				"properties": map[string]any{
+					"text":    map[string]any{"type": "string"},
+					"replies": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/comment"}},
+				},
+			},
+		},
+	}
+	v := NewDefaultValidator()
+
+	valid := map[string]any{
+		"text": "root",
+		"replies": []any{
+			map[string]any{"text": "child", "replies": []any{
+				map[string]any{"text": "grandchild"},
+			}},
+			map[string]any{"text": "sibling", "replies": []any{}},
+		},
+	}
+	if err := v.Validate(tree, valid); err != nil {
+		t.Errorf("Validate(multi-level tree) error = %v", err)
+	}
+
+	malformed := map[string]any{
+		"text": "root",
+		"replies": []any{
+			map[string]any{"text": "child", "replies": []any{map[string]any{"text": 3}}},
+		},
+	}
+	if err := v.Validate(tree, malformed); err == nil {
+		t.Error("Validate(malformed tree) error = nil, want failure")
+	}
+	if pointer, _, _ := v.FirstError(tree, malformed); pointer != "/replies/0/replies/0/text" {
+		t.Errorf("FirstError(malformed tree) pointer = %q, want /replies/0/replies/0/text", pointer)
+	}
+
+	cycles := map[string]map[string]any{
+		"mutual refs": {
+			"$ref": "#/$defs/a",
+			"$defs": map[string]any{
+				"a": map[string]any{"$ref": "#/$defs/b"},
+				"b": map[string]any{"$ref": "#/$defs/a"},
+			},
+		},
+		"self via allOf": {
+			"type":  "object",
+			"allOf": []any{map[string]any{"$ref": "#"}},
+		},
+	}
+	for name, schema := range cycles {
+		t.Run(name, func(t *testing.T) {
+			if err := ValidateSchema(schema); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("ValidateSchema() error = %v, want ErrInvalidSchema", err)
+			}
+			if err := v.Validate(schema, 1); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("Validate() error = %v, want ErrInvalidSchema", err)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_WithSharedInputDefs(t *testing.T) {
+	tool := &Tool{Tool: mcp.Tool{
+		Name: "get_item",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"item": map[string]any{"$ref": "#/$defs/item"}},
+			"$defs": map[string]any{
+				"item": map[string]any{
+					"type":       "object",
+					"required":   []any{"id"},
+					"properties": map[string]any{"id": map[string]any{"type": "string"}},
+				},
+			},
+		},
+		OutputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"result": map[string]any{"$ref": "#/$defs/item"}},
+		},
+	}}
+
+	if err := NewDefaultValidator().ValidateOutput(tool, map[string]any{"result": map[string]any{"id": "a"}}); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("ValidateOutput() without option error = %v, want ErrInvalidSchema for dangling ref", err)
+	}
+
+	v := NewDefaultValidator(WithSharedInputDefs())
+	if err := v.ValidateOutput(tool, map[string]any{"result": map[string]any{"id": "a"}}); err != nil {
+		t.Errorf("ValidateOutput() error = %v", err)
+	}
+	if err := v.ValidateOutput(tool, map[string]any{"result": map[string]any{"id": 1}}); err == nil {
+		t.Error("ValidateOutput() error = nil, want failure from shared def")
+	}
+
+	external := *tool
+	external.OutputSchema = map[string]any{"$ref": "https://example.com/item.json"}
+	if err := v.ValidateOutput(&external, map[string]any{}); !errors.Is(err, ErrExternalRef) {
+		t.Errorf("ValidateOutput() error = %v, want ErrExternalRef", err)
+	}
+	if _, ok := tool.OutputSchema.(map[string]any)["$defs"]; ok {
+		t.Error("ValidateOutput() modified the tool's OutputSchema")
+	}
+}
@@ -0,0 +1,716 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// violation is a single validation failure located in the instance.
+//
+// jsonschema-go reports only the schema location of the first failure, so the
+// collector below re-walks the instance alongside the schema to recover
+// instance paths. The library stays the source of truth for whether a keyword
+// holds; the walk only decides where to look.
+type violation struct {
+	// instancePath is the JSON Pointer of the failing instance node ("" for
+	// the root).
+	instancePath string
+	// schemaPath is the JSON Pointer of the failing keyword in the schema.
+	schemaPath string
+	keyword    string
+	message    string
+}
+
+func (e violation) Error() string {
+	location := e.instancePath
+	if location == "" {
+		location = "(root)"
+	}
+	if e.keyword == "" {
+		return fmt.Sprintf("%s: %s", location, e.message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, e.keyword, e.message)
+}
+
+// ValidationError describes an instance that does not satisfy its schema.
+// DefaultValidator.Validate, ValidateInput and ValidateOutput return it for
+// validation failures, so callers can use errors.As to report the failing
+// field; schema problems are still reported through the sentinel errors.
+//
+// The fields describe the first failure. Errors lists every failure, in the
+// same deterministic order as FirstError, when there is more than one.
+type ValidationError struct {
+	// InstancePath is the JSON Pointer of the failing instance node ("" for
+	// the root), e.g. "/items/2/name".
+	InstancePath string
+	// SchemaPath is the JSON Pointer of the failing keyword in the schema.
+	SchemaPath string
+	// Keyword is the schema keyword that failed, e.g. "required" or "type".
+	Keyword string
+	// Message describes the failure.
+	Message string
+	// Errors holds all failures when there is more than one.
+	Errors []ValidationError
+
+	// err is the jsonschema-go error the failure was derived from.
+	err error
+	// all makes Error report every entry of Errors (see WithAllErrors).
+	all bool
+}
+
+// Error reports the failure as "validation failed: " followed by the
+// underlying jsonschema-go message. With WithAllErrors, each entry of Errors
+// is reported that way on its own line.
+func (e *ValidationError) Error() string {
+	if e.all && len(e.Errors) > 1 {
+		lines := make([]string, len(e.Errors))
+		for i := range e.Errors {
+			lines[i] = e.Errors[i].Error()
+		}
+		return strings.Join(lines, "\n")
+	}
+	if e.err != nil {
+		return "validation failed: " + e.err.Error()
+	}
+	return "validation failed: " + e.violation().Error()
+}
+
+// Unwrap returns the underlying jsonschema-go error, if any.
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+func (e *ValidationError) violation() violation {
+	return violation{
+		instancePath: e.InstancePath,
+		schemaPath:   e.SchemaPath,
+		keyword:      e.Keyword,
+		message:      e.Message,
+	}
+}
+
+// validationErrors converts violations into one *ValidationError each.
+func validationErrors(violations []violation) []error {
+	if len(violations) == 0 {
+		return nil
+	}
+	errs := make([]error, len(violations))
+	for i, v := range violations {
+		errs[i] = &ValidationError{
+			InstancePath: v.instancePath,
+			SchemaPath:   v.schemaPath,
+			Keyword:      v.keyword,
+			Message:      v.message,
+		}
+	}
+	return errs
+}
+
+// newValidationError builds a ValidationError from the collected violations
+// of a failed validation, falling back to cause alone when none were found.
+func newValidationError(violations []violation, cause error) *ValidationError {
+	if len(violations) == 0 {
+		violations = []violation{violationFromError("", "", cause)}
+	}
+	errs := make([]ValidationError, len(violations))
+	for i, v := range violations {
+		errs[i] = ValidationError{
+			InstancePath: v.instancePath,
+			SchemaPath:   v.schemaPath,
+			Keyword:      v.keyword,
+			Message:      v.message,
+		}
+	}
+	ve := errs[0]
+	if len(errs) > 1 {
+		ve.Errors = errs
+	}
+	ve.err = cause
+	return &ve
+}
+
+const (
+	// violationRootURI and violationCheckURI are the synthetic locations the
+	// collector loads the schema and its keyword checks under. They are never
+	// fetched.
+	violationRootURI  = "https://toolmodel.invalid/root.json"
+	violationCheckURI = "https://toolmodel.invalid/check.json"
+
+	// maxRefHops bounds chains of "$ref" that do not descend into the
+	// instance, guarding against definition cycles.
+	maxRefHops = 64
+)
+
+// collectorSkipKeywords are not checked individually: they are annotations,
+// are handled structurally by collect, or only make sense together with a
+// sibling keyword.
+var collectorSkipKeywords = map[string]bool{
+	"$anchor": true, "$comment": true, "$defs": true, "$dynamicAnchor": true,
+	"$id": true, "$ref": true, "$schema": true, "$vocabulary": true,
+	"additionalItems": true, "additionalProperties": true, "allOf": true,
+	"default": true, "definitions": true, "deprecated": true,
+	"description": true, "else": true, "examples": true, "if": true,
+	"items": true, "maxContains": true, "minContains": true,
+	"patternProperties": true, "prefixItems": true, "properties": true,
+	"readOnly": true, "required": true, "then": true, "title": true,
+	"unevaluatedItems": true, "unevaluatedProperties": true,
+	"writeOnly": true,
+}
+
+// violations validates instance against schema and returns every failure
+// located in the instance, in a deterministic order: object properties are
+// visited in sorted order, array items by index, and missing required
+// properties in the order the schema declares them. It returns nil when the
+// instance is valid; schema problems are returned as an error.
+func (v *DefaultValidator) violations(schema any, instance any) ([]violation, error) {
+	compiled, err := v.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+	return v.violationsOf(compiled, instance)
+}
+
+// violationsOf is violations for a schema that has already been compiled.
+func (v *DefaultValidator) violationsOf(compiled *compiledSchema, instance any) ([]violation, error) {
+	// Walk a decoded copy so structs and typed maps look like JSON.
+	var generic any
+	if err := jsonRoundTrip(instance, &generic); err != nil {
+		return nil, fmt.Errorf("validation failed: %v", err)
+	}
+	verr := compiled.resolved.Validate(generic)
+	if verr == nil && !v.assertFormats {
+		return nil, nil
+	}
+
+	c, err := compiled.collector()
+	if err != nil {
+		return nil, err
+	}
+	if v.assertFormats {
+		c.formats = v.formatCheckers()
+	}
+	c.collect("", "", generic, "", 0)
+	if verr != nil && len(c.out) == c.formatViolations && c.excused == 0 {
+		c.out = append(c.out, violationFromError("", "", verr))
+	}
+	return c.out, nil
+}
+
+// violationCollector walks an instance alongside its schema, checking
+// individual keywords with jsonschema-go.
+type violationCollector struct {
+	root       map[string]any
+	rootSchema *jsonschema.Schema
+	uri        string
+	// checks may be shared by collectors for the same compiled schema.
+	checks   *resolvedChecks
+	patterns map[string]*regexp.Regexp
+	out      []violation
+	// formats, when set, asserts "format" on string instances and makes the
+	// walk visit valid nodes too. formatViolations counts the resulting
+	// entries of out, and excused counts "oneOf" and "not" failures that
+	// jsonschema-go reports but the asserted formats overturn.
+	formats          map[string]func(string) error
+	formatViolations int
+	excused          int
+}
+
+func newViolationCollector(root map[string]any) (*violationCollector, error) {
+	c := &violationCollector{
+		root:     root,
+		uri:      violationRootURI,
+		checks:   new(resolvedChecks),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	// Keep an absolute $id so refs written against it still resolve;
+	// otherwise load the document under the synthetic URI.
+	doc := root
+	if id, ok := root["$id"].(string); ok {
+		if u, err := url.Parse(id); err == nil && u.IsAbs() {
+			u.Fragment = ""
+			c.uri = u.String()
+		} else {
+			doc = make(map[string]any, len(root))
+			for k, v := range root {
+				doc[k] = v
+			}
+			delete(doc, "$id")
+		}
+	}
+	var s jsonschema.Schema
+	if err := jsonRoundTrip(doc, &s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	c.rootSchema = &s
+	return c, nil
+}
+
+// collect records the failures of instance against the subschema at
+// schemaPath. base is the pointer of the schema resource that local refs
+// resolve against, and hops counts refs followed since the last descent into
+// the instance.
+func (c *violationCollector) collect(schemaPath, base string, instance any, instancePath string, hops int) {
+	node, _ := resolveJSONPointer(c.root, schemaPath)
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			c.add(instancePath, schemaPath, "false", "false schema does not allow any value")
+		}
+		return
+	case map[string]any:
+		if schemaPath != "" {
+			if _, ok := n["$id"].(string); ok {
+				base = schemaPath
+			}
+		}
+		nodeErr := c.check(c.ref(schemaPath), instance)
+		if nodeErr == nil && c.formats == nil {
+			return
+		}
+		before, beforeFormats, beforeExcused := len(c.out), c.formatViolations, c.excused
+		c.collectNode(n, schemaPath, base, instance, instancePath, hops, nodeErr != nil)
+		if nodeErr != nil && len(c.out)-before == c.formatViolations-beforeFormats && c.excused == beforeExcused {
+			c.out = append(c.out, violationFromError(instancePath, schemaPath, nodeErr))
+		}
+	}
+}
+
+// collectNode descends into the subschemas of n and, when the node failed,
+// checks its remaining keywords one by one.
+func (c *violationCollector) collectNode(n map[string]any, schemaPath, base string, instance any, instancePath string, hops int, failed bool) {
+	if ref, ok := n["$ref"].(string); ok {
+		if target, ok := localRefTarget(ref, base); ok && hops < maxRefHops {
+			c.collect(target, base, instance, instancePath, hops+1)
+		}
+	}
+	if list, ok := n["allOf"].([]any); ok {
+		for i := range list {
+			c.collect(schemaPath+"/allOf/"+strconv.Itoa(i), base, instance, instancePath, hops)
+		}
+	}
+	if _, ok := n["if"]; ok {
+		branch := "else"
+		if c.check(c.ref(schemaPath+"/if"), instance) == nil {
+			branch = "then"
+		}
+		if _, ok := n[branch]; ok {
+			c.collect(schemaPath+"/"+branch, base, instance, instancePath, hops)
+		}
+	}
+	if c.formats != nil {
+		c.collectBranches(n, schemaPath, base, instance, instancePath, hops)
+	}
+
+	switch inst := instance.(type) {
+	case map[string]any:
+		c.collectObject(n, schemaPath, base, inst, instancePath)
+	case []any:
+		c.collectArray(n, schemaPath, base, inst, instancePath)
+	}
+
+	for _, kw := range sortedKeys(n) {
+		if kw == "format" && c.formats != nil {
+			c.checkFormat(n, schemaPath, instance, instancePath)
+			continue
+		}
+		if c.formats != nil && (kw == "anyOf" || kw == "oneOf" || kw == "not") {
+			continue // checked by collectBranches
+		}
+		if !failed || collectorSkipKeywords[kw] {
+			continue
+		}
+		check := map[string]any{kw: c.rewrite(schemaPath, kw, n[kw])}
+		if kw == "contains" {
+			for _, sibling := range []string{"minContains", "maxContains"} {
+				if v, ok := n[sibling]; ok {
+					check[sibling] = v
+				}
+			}
+		}
+		if err := c.check(check, instance); err != nil {
+			c.out = append(c.out, violationFromError(instancePath, schemaPath+"/"+escapeJSONPointer(kw), err))
+		}
+	}
+}
+
+func (c *violationCollector) collectObject(n map[string]any, schemaPath, base string, inst map[string]any, instancePath string) {
+	for _, name := range schemaRequired(n) {
+		if _, ok := inst[name]; !ok {
+			c.add(instancePath, schemaPath+"/required", "required", fmt.Sprintf("missing property %q", name))
+		}
+	}
+
+	props, _ := n["properties"].(map[string]any)
+	patterns, _ := n["patternProperties"].(map[string]any)
+	patternKeys := sortedKeys(patterns)
+	for _, key := range sortedKeys(inst) {
+		child := instancePath + "/" + escapeJSONPointer(key)
+		matched := false
+		if _, ok := props[key]; ok {
+			matched = true
+			c.collect(schemaPath+"/properties/"+escapeJSONPointer(key), base, inst[key], child, 0)
+		}
+		for _, p := range patternKeys {
+			if re := c.pattern(p); re != nil && re.MatchString(key) {
+				matched = true
+				c.collect(schemaPath+"/patternProperties/"+escapeJSONPointer(p), base, inst[key], child, 0)
+			}
+		}
+		if matched {
+			continue
+		}
+		switch ap := n["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				c.add(child, schemaPath+"/additionalProperties", "additionalProperties", fmt.Sprintf("unexpected property %q", key))
+			}
+		case map[string]any:
+			c.collect(schemaPath+"/additionalProperties", base, inst[key], child, 0)
+		}
+	}
+}
+
+func (c *violationCollector) collectArray(n map[string]any, schemaPath, base string, inst []any, instancePath string) {
+	prefix, prefixKeyword := 0, "prefixItems"
+	tuple, ok := n["prefixItems"].([]any)
+	if !ok {
+		// Draft-07 tuple form.
+		if tuple, ok = n["items"].([]any); ok {
+			prefixKeyword = "items"
+		}
+	}
+	for i := range inst {
+		if i >= len(tuple) {
+			break
+		}
+		c.collect(schemaPath+"/"+prefixKeyword+"/"+strconv.Itoa(i), base, inst[i], instancePath+"/"+strconv.Itoa(i), 0)
+		prefix++
+	}
+
+	rest := "items"
+	if prefixKeyword == "items" {
+		rest = "additionalItems"
+	}
+	if _, ok := n[rest].([]any); ok {
+		return
+	}
+	if _, ok := n[rest]; !ok {
+		return
+	}
+	for i := prefix; i < len(inst); i++ {
+		c.collect(schemaPath+"/"+rest, base, inst[i], instancePath+"/"+strconv.Itoa(i), 0)
+	}
+}
+
+// collectBranches checks "anyOf", "oneOf" and "not" with formats asserted: a
+// branch matches only when it has no violations, formats included. Formats
+// can turn a match reported by jsonschema-go into a failure, such as an
+// "anyOf" whose only accepted branch has a bad email, or a failure into a
+// match, such as a "not" whose branch fails only on its format.
+func (c *violationCollector) collectBranches(n map[string]any, schemaPath, base string, instance any, instancePath string, hops int) {
+	for _, kw := range []string{"anyOf", "oneOf"} {
+		list, ok := n[kw].([]any)
+		if !ok {
+			continue
+		}
+		at := schemaPath + "/" + kw
+		var matched, accepted []string
+		for i := range list {
+			branch := at + "/" + strconv.Itoa(i)
+			if c.matches(branch, base, instance, instancePath, hops) {
+				matched = append(matched, branch)
+			}
+			if c.check(c.ref(branch), instance) == nil {
+				accepted = append(accepted, branch)
+			}
+		}
+		libErr := c.check(map[string]any{kw: c.rewrite(schemaPath, kw, list)}, instance)
+		switch {
+		case len(matched) == 1 || (kw == "anyOf" && len(matched) > 1):
+			if libErr != nil {
+				c.excused++
+			}
+		case len(matched) == 0 && len(accepted) > 0:
+			// Only formats rule the accepted branches out; report them.
+			for _, branch := range accepted {
+				c.collect(branch, base, instance, instancePath, hops)
+			}
+		case libErr != nil:
+			c.out = append(c.out, violationFromError(instancePath, at, libErr))
+		default:
+			c.add(instancePath, at, kw, fmt.Sprintf("matches %d schemas, want exactly one", len(matched)))
+		}
+	}
+
+	if _, ok := n["not"]; ok {
+		at := schemaPath + "/not"
+		libErr := c.check(map[string]any{"not": c.ref(at)}, instance)
+		switch {
+		case !c.matches(at, base, instance, instancePath, hops):
+			if libErr != nil {
+				c.excused++
+			}
+		case libErr != nil:
+			c.out = append(c.out, violationFromError(instancePath, at, libErr))
+		default:
+			c.add(instancePath, at, "not", "value matches a schema it must not match")
+		}
+	}
+}
+
+// matches reports whether instance satisfies the subschema at schemaPath,
+// formats included, without recording any violations.
+func (c *violationCollector) matches(schemaPath, base string, instance any, instancePath string, hops int) bool {
+	out, formatViolations, excused := c.out, c.formatViolations, c.excused
+	c.collect(schemaPath, base, instance, instancePath, hops)
+	ok := len(c.out) == len(out)
+	c.out, c.formatViolations, c.excused = out, formatViolations, excused
+	return ok
+}
+
+// checkFormat asserts the "format" of n on a string instance. Formats
+// without a checker are annotations.
+func (c *violationCollector) checkFormat(n map[string]any, schemaPath string, instance any, instancePath string) {
+	format, _ := n["format"].(string)
+	s, ok := instance.(string)
+	check := c.formats[format]
+	if !ok || check == nil {
+		return
+	}
+	if err := check(s); err != nil {
+		c.add(instancePath, schemaPath+"/format", "format", fmt.Sprintf("%q is not a valid %s: %v", s, format, err))
+		c.formatViolations++
+	}
+}
+
+func (c *violationCollector) add(instancePath, schemaPath, keyword, message string) {
+	c.out = append(c.out, violation{
+		instancePath: instancePath,
+		schemaPath:   schemaPath,
+		keyword:      keyword,
+		message:      message,
+	})
+}
+
+// ref returns a schema that defers to the node at schemaPath, so the node can
+// be checked in isolation while its own references still resolve.
+func (c *violationCollector) ref(schemaPath string) map[string]any {
+	return map[string]any{"$ref": c.uri + "#" + (&url.URL{Fragment: schemaPath}).EscapedFragment()}
+}
+
+// rewrite replaces the subschemas inside a keyword's value with refs to
+// their location, so a single keyword can be checked on its own.
+func (c *violationCollector) rewrite(schemaPath, kw string, value any) any {
+	at := schemaPath + "/" + escapeJSONPointer(kw)
+	switch val := value.(type) {
+	case map[string]any:
+		if slices.Contains(singleSchemaKeywords, kw) {
+			return c.ref(at)
+		}
+		if slices.Contains(schemaMapKeywords, kw) {
+			out := make(map[string]any, len(val))
+			for k, sub := range val {
+				switch sub.(type) {
+				case map[string]any, bool:
+					out[k] = c.ref(at + "/" + escapeJSONPointer(k))
+				default:
+					out[k] = sub
+				}
+			}
+			return out
+		}
+	case bool:
+		if slices.Contains(singleSchemaKeywords, kw) {
+			return c.ref(at)
+		}
+	case []any:
+		if slices.Contains(schemaListKeywords, kw) {
+			out := make([]any, len(val))
+			for i := range val {
+				out[i] = c.ref(at + "/" + strconv.Itoa(i))
+			}
+			return out
+		}
+	}
+	return value
+}
+
+// resolvedChecks memoizes the keyword checks of one schema document by the
+// JSON encoding of the check. Resolution is serialized, as every check loads
+// the same root schema; lookups are not.
+type resolvedChecks struct {
+	mu       sync.Mutex
+	resolved sync.Map // string → *jsonschema.Resolved
+}
+
+// check validates instance against a standalone schema that may refer into
+// the collector's root document. The resolved schema is memoized, so
+// checking the same keyword on many instance nodes resolves it once.
+func (c *violationCollector) check(schema map[string]any, instance any) error {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	key := string(data)
+	if r, ok := c.checks.resolved.Load(key); ok {
+		return r.(*jsonschema.Resolved).Validate(instance)
+	}
+	c.checks.mu.Lock()
+	defer c.checks.mu.Unlock()
+	if r, ok := c.checks.resolved.Load(key); ok {
+		return r.(*jsonschema.Resolved).Validate(instance)
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	resolved, err := s.Resolve(&jsonschema.ResolveOptions{
+		BaseURI: violationCheckURI,
+		Loader: func(uri *url.URL) (*jsonschema.Schema, error) {
+			u := *uri
+			u.Fragment = ""
+			if u.String() == c.uri {
+				return c.rootSchema, nil
+			}
+			return nil, fmt.Errorf("%w: %s", ErrExternalRef, uri.String())
+		},
+	})
+	if err != nil {
+		return err
+	}
+	c.checks.resolved.Store(key, resolved)
+	return resolved.Validate(instance)
+}
+
+func (c *violationCollector) pattern(p string) *regexp.Regexp {
+	re, ok := c.patterns[p]
+	if !ok {
+		re, _ = regexp.Compile(p)
+		c.patterns[p] = re
+	}
+	return re
+}
+
+// localRefTarget returns the schema pointer a "#/..." reference resolves to
+// within the resource rooted at base. Anchors and non-local refs are not
+// followed.
+func localRefTarget(ref, base string) (string, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil || (fragment != "" && !strings.HasPrefix(fragment, "/")) {
+		return "", false
+	}
+	return base + fragment, true
+}
+
+// violationFromError converts a jsonschema-go error into a violation, keeping
+// only the innermost "keyword: message" part of the error text.
+func violationFromError(instancePath, schemaPath string, err error) violation {
+	msg, location := err.Error(), ""
+	for strings.HasPrefix(msg, "validating ") {
+		i := strings.Index(msg, ": ")
+		if i < 0 {
+			break
+		}
+		location, msg = msg[len("validating "):i], msg[i+2:]
+	}
+	keyword, rest, ok := strings.Cut(msg, ": ")
+	// Map-valued keywords are reported with their key, e.g.
+	// `dependentRequired["a"]`.
+	if i := strings.IndexByte(keyword, '['); i > 0 {
+		keyword = keyword[:i]
+	}
+	if !ok || strings.ContainsAny(keyword, " \"") {
+		keyword, rest = "", msg
+	}
+	// jsonschema-go represents a false subschema as {"not": {}}; report the
+	// keyword that held it instead.
+	if keyword == "not" && rest == "validated against <anonymous schema>" && strings.HasPrefix(location, "/") {
+		keyword = jsonPointerUnescaper.Replace(location[strings.LastIndex(location, "/")+1:])
+		rest = "false schema does not allow any value"
+	}
+	return violation{
+		instancePath: instancePath,
+		schemaPath:   schemaPath,
+		keyword:      keyword,
+		message:      rest,
+	}
+}
+
+// ErrorCategory buckets validation failures for metrics.
+type ErrorCategory string
+
+// Validation error categories returned by ClassifyValidationError.
+const (
+	// ErrorCategoryMissingRequired covers "required" and "dependentRequired".
+	ErrorCategoryMissingRequired ErrorCategory = "MissingRequired"
+	// ErrorCategoryTypeMismatch covers "type".
+	ErrorCategoryTypeMismatch ErrorCategory = "TypeMismatch"
+	// ErrorCategoryEnumViolation covers "enum" and "const".
+	ErrorCategoryEnumViolation ErrorCategory = "EnumViolation"
+	// ErrorCategoryRangeViolation covers numeric bounds, "multipleOf", and
+	// length, item, and property count limits.
+	ErrorCategoryRangeViolation ErrorCategory = "RangeViolation"
+	// ErrorCategoryFormatViolation covers "format" and "pattern".
+	ErrorCategoryFormatViolation ErrorCategory = "FormatViolation"
+	// ErrorCategoryOther covers every other failure, including errors that
+	// are not validation failures.
+	ErrorCategoryOther ErrorCategory = "Other"
+)
+
+var keywordCategories = map[string]ErrorCategory{
+	"required":          ErrorCategoryMissingRequired,
+	"dependentRequired": ErrorCategoryMissingRequired,
+	"type":              ErrorCategoryTypeMismatch,
+	"enum":              ErrorCategoryEnumViolation,
+	"const":             ErrorCategoryEnumViolation,
+	"minimum":           ErrorCategoryRangeViolation,
+	"maximum":           ErrorCategoryRangeViolation,
+	"exclusiveMinimum":  ErrorCategoryRangeViolation,
+	"exclusiveMaximum":  ErrorCategoryRangeViolation,
+	"multipleOf":        ErrorCategoryRangeViolation,
+	"minLength":         ErrorCategoryRangeViolation,
+	"maxLength":         ErrorCategoryRangeViolation,
+	"minItems":          ErrorCategoryRangeViolation,
+	"maxItems":          ErrorCategoryRangeViolation,
+	"minProperties":     ErrorCategoryRangeViolation,
+	"maxProperties":     ErrorCategoryRangeViolation,
+	"format":            ErrorCategoryFormatViolation,
+	"pattern":           ErrorCategoryFormatViolation,
+}
+
+// ClassifyValidationError returns the category of the failing keyword in err,
+// an error returned by the DefaultValidator (directly or wrapped). Errors it
+// does not recognize, including nil and schema errors, are ErrorCategoryOther.
+func ClassifyValidationError(err error) ErrorCategory {
+	if err == nil {
+		return ErrorCategoryOther
+	}
+	var keyword string
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		keyword = ve.Keyword
+	} else {
+		msg := err.Error()
+		i := strings.Index(msg, "validating ")
+		if i < 0 {
+			return ErrorCategoryOther
+		}
+		keyword = violationFromError("", "", errors.New(msg[i:])).keyword
+	}
+	if category, ok := keywordCategories[keyword]; ok {
+		return category
+	}
+	return ErrorCategoryOther
+}
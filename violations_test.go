@@ -0,0 +1,342 @@
+package toolmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestDefaultValidator_violations(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"id", "items", "owner"},
+		"properties": map[string]any{
+			"id": map[string]any{"type": "string", "minLength": 3},
+			"items": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"$ref": "#/$defs/item"},
+			},
+			"mode": map[string]any{"enum": []any{"fast", "slow"}},
+		},
+		"additionalProperties": false,
+		"$defs": map[string]any{
+			"item": map[string]any{
+				"type":       "object",
+				"required":   []any{"name"},
+				"properties": map[string]any{"name": map[string]any{"type": "string"}},
+			},
+		},
+	}
+	instance := map[string]any{
+		"id":    "ab",
+		"items": []any{map[string]any{"name": "a"}, map[string]any{}, map[string]any{"name": 3}},
+		"mode":  "medium",
+		"extra": true,
+	}
+
+	got, err := NewDefaultValidator().violations(schema, instance)
+	if err != nil {
+		t.Fatalf("violations() error = %v", err)
+	}
+	type loc struct{ path, keyword string }
+	var locs []loc
+	for _, v := range got {
+		locs = append(locs, loc{v.instancePath, v.keyword})
+	}
+	want := []loc{
+		{"", "required"},
+		{"/extra", "additionalProperties"},
+		{"/id", "minLength"},
+		{"/items/1", "required"},
+		{"/items/2/name", "type"},
+		{"/mode", "enum"},
+	}
+	if !reflect.DeepEqual(locs, want) {
+		t.Errorf("violations() locations = %v, want %v", locs, want)
+	}
+	if got[0].message != `missing property "owner"` {
+		t.Errorf("required message = %q", got[0].message)
+	}
+	if got[4].schemaPath != "/$defs/item/properties/name/type" {
+		t.Errorf("schemaPath = %q, want /$defs/item/properties/name/type", got[4].schemaPath)
+	}
+}
+
+func TestDefaultValidator_violations_Combinators(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"kind": {"type": "string"},
+			"value": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+		},
+		"if": {"properties": {"kind": {"const": "limit"}}},
+		"then": {"properties": {"value": {"type": "integer", "minimum": 1}}},
+		"unevaluatedProperties": false
+	}`)
+
+	tests := []struct {
+		name     string
+		instance map[string]any
+		want     []string
+	}{
+		{"valid", map[string]any{"kind": "limit", "value": 2}, nil},
+		{"then branch", map[string]any{"kind": "limit", "value": 0}, []string{"/value minimum"}},
+		{"anyOf", map[string]any{"kind": "other", "value": true}, []string{"/value anyOf"}},
+		{"unevaluated falls back to node", map[string]any{"kind": "x", "other": 1}, []string{" unevaluatedProperties"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDefaultValidator().violations(schema, tt.instance)
+			if err != nil {
+				t.Fatalf("violations() error = %v", err)
+			}
+			var locs []string
+			for _, v := range got {
+				locs = append(locs, v.instancePath+" "+v.keyword)
+			}
+			if !reflect.DeepEqual(locs, tt.want) {
+				t.Errorf("violations() = %v, want %v", locs, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultValidator_violations_RecursiveRef(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"value":    map[string]any{"type": "integer"},
+			"children": map[string]any{"type": "array", "items": map[string]any{"$ref": "#"}},
+		},
+	}
+	instance := map[string]any{
+		"value": 1,
+		"children": []any{
+			map[string]any{"value": 2, "children": []any{map[string]any{"value": "x"}}},
+		},
+	}
+	got, err := NewDefaultValidator().violations(schema, instance)
+	if err != nil {
+		t.Fatalf("violations() error = %v", err)
+	}
+	if len(got) != 1 || got[0].instancePath != "/children/0/children/0/value" {
+		t.Errorf("violations() = %v, want one failure at /children/0/children/0/value", got)
+	}
+}
+
+func TestDefaultValidator_violations_MemoizesChecks(t *testing.T) {
+	schema := map[string]any{
+		"type": "array",
+		"items": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"email": map[string]any{"type": "string", "format": "email"}},
+		},
+	}
+	items := make([]any, 50)
+	for i := range items {
+		items[i] = map[string]any{"email": "a@example.com"}
+	}
+	v := NewDefaultValidator(WithFormatAssertion(true), WithSchemaCacheSize(4))
+	compiled, err := v.resolve(schema)
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	countChecks := func() int {
+		c, err := compiled.collector()
+		if err != nil {
+			t.Fatalf("collector() error = %v", err)
+		}
+		n := 0
+		c.checks.resolved.Range(func(any, any) bool { n++; return true })
+		return n
+	}
+
+	if err := v.Validate(schema, items); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	first := countChecks()
+	if first == 0 || first >= len(items) {
+		t.Fatalf("resolved %d checks for %d items, want one per keyword", first, len(items))
+	}
+	if err := v.Validate(schema, append(items, map[string]any{"email": "nope"})); err == nil {
+		t.Fatal("Validate() error = nil, want format failure")
+	}
+	if got := countChecks(); got != first {
+		t.Errorf("resolved %d checks after revalidating, want %d reused", got, first)
+	}
+}
+
+func TestClassifyValidationError(t *testing.T) {
+	v := NewDefaultValidator()
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "pattern": "^[a-z]+$"},
+			"mode":  map[string]any{"enum": []any{"fast", "slow"}},
+			"count": map[string]any{"type": "integer", "minimum": 1},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		instance any
+		want     ErrorCategory
+	}{
+		{"missing required", map[string]any{}, ErrorCategoryMissingRequired},
+		{"type mismatch", map[string]any{"name": 3}, ErrorCategoryTypeMismatch},
+		{"enum", map[string]any{"name": "a", "mode": "medium"}, ErrorCategoryEnumViolation},
+		{"range", map[string]any{"name": "a", "count": 0}, ErrorCategoryRangeViolation},
+		{"pattern", map[string]any{"name": "A"}, ErrorCategoryFormatViolation},
+		{"root type", "not an object", ErrorCategoryTypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(schema, tt.instance)
+			if got := ClassifyValidationError(err); got != tt.want {
+				t.Errorf("ClassifyValidationError(%v) = %s, want %s", err, got, tt.want)
+			}
+			_, _, firstErr := v.FirstError(schema, tt.instance)
+			if got := ClassifyValidationError(firstErr); got != tt.want {
+				t.Errorf("ClassifyValidationError(FirstError %v) = %s, want %s", firstErr, got, tt.want)
+			}
+		})
+	}
+
+	for _, err := range []error{nil, errors.New("boom"), ErrInvalidSchema} {
+		if got := ClassifyValidationError(err); got != ErrorCategoryOther {
+			t.Errorf("ClassifyValidationError(%v) = %s, want Other", err, got)
+		}
+	}
+}
+
+func TestDefaultValidator_ValidationError(t *testing.T) {
+	v := NewDefaultValidator()
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"name", "email"},
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"age":  map[string]any{"type": "integer", "minimum": 0},
+		},
+	}
+
+	t.Run("single failure", func(t *testing.T) {
+		err := v.Validate(schema, map[string]any{"name": "ada", "email": "a@b", "age": -1})
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("Validate() error = %T %v, want *ValidationError", err, err)
+		}
+		if ve.InstancePath != "/age" || ve.SchemaPath != "/properties/age/minimum" || ve.Keyword != "minimum" {
+			t.Errorf("ValidationError = %+v, want /age at /properties/age/minimum", ve)
+		}
+		if ve.Message == "" || ve.Errors != nil {
+			t.Errorf("ValidationError message = %q, errors = %v", ve.Message, ve.Errors)
+		}
+	})
+
+	t.Run("multiple failures", func(t *testing.T) {
+		tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema}}
+		err := v.ValidateInput(tool, map[string]any{"age": "old"})
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("ValidateInput() error = %T %v, want *ValidationError", err, err)
+		}
+		var got []string
+		for _, e := range ve.Errors {
+			got = append(got, e.InstancePath+" "+e.Keyword+": "+e.Message)
+		}
+		want := []string{
+			` required: missing property "name"`,
+			` required: missing property "email"`,
+			`/age type: old has type "string", want "integer"`,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ValidationError.Errors = %q, want %q", got, want)
+		}
+		if ve.InstancePath != "" || ve.Keyword != "required" || ve.Message != `missing property "name"` {
+			t.Errorf("ValidationError = %+v, want the first failure", ve)
+		}
+	})
+
+	t.Run("schema errors keep sentinels", func(t *testing.T) {
+		err := v.Validate(map[string]any{"$ref": "#/$defs/missing"}, map[string]any{})
+		var ve *ValidationError
+		if errors.As(err, &ve) || !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("Validate() error = %v, want ErrInvalidSchema only", err)
+		}
+	})
+}
+
+func TestDefaultValidator_ValidateAll(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"name", "email", "zip"},
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string"},
+			"email": map[string]any{"type": "string"},
+			"zip":   map[string]any{"type": "string"},
+			"age":   map[string]any{"type": "integer", "minimum": 0},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	instance := map[string]any{"age": -1, "tags": []any{"a", 2}}
+	want := []string{
+		`validation failed: (root): required: missing property "name"`,
+		`validation failed: (root): required: missing property "email"`,
+		`validation failed: (root): required: missing property "zip"`,
+		`validation failed: /age: minimum: -1/1 is less than 0.000000`,
+		`validation failed: /tags/1: type: 2 has type "integer", want "string"`,
+	}
+	v := NewDefaultValidator()
+
+	for i := 0; i < 3; i++ {
+		errs := v.ValidateAll(schema, instance)
+		var got []string
+		for _, err := range errs {
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("ValidateAll() error %T, want *ValidationError", err)
+			}
+			got = append(got, err.Error())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ValidateAll() =\n%q\nwant\n%q", got, want)
+		}
+	}
+
+	if errs := v.ValidateAll(schema, map[string]any{"name": "a", "email": "b", "zip": "c"}); errs != nil {
+		t.Errorf("ValidateAll() valid instance = %v, want nil", errs)
+	}
+	if errs := v.ValidateAll("bogus", instance); len(errs) != 1 || !errors.Is(errs[0], ErrInvalidSchema) {
+		t.Errorf("ValidateAll() invalid schema = %v, want [ErrInvalidSchema]", errs)
+	}
+
+	tool := &Tool{Tool: mcp.Tool{Name: "t", InputSchema: schema, OutputSchema: schema}}
+	single := v.ValidateInput(tool, instance)
+	if n := strings.Count(single.Error(), "\n"); n != 0 {
+		t.Errorf("ValidateInput() error has %d extra lines, want a single failure", n)
+	}
+	for name, err := range map[string]error{
+		"ValidateInputOpt":  v.ValidateInputOpt(tool, instance, WithAllErrors()),
+		"ValidateOutputOpt": v.ValidateOutputOpt(tool, instance, WithAllErrors()),
+	} {
+		if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s() =\n%q\nwant\n%q", name, got, want)
+		}
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Message != `missing property "name"` {
+			t.Errorf("%s() first failure = %v", name, ve)
+		}
+		if ve != nil && len(ve.Errors) != len(want) {
+			t.Errorf("%s() Errors has %d entries, want %d", name, len(ve.Errors), len(want))
+		}
+	}
+	if v.allErrors {
+		t.Error("ValidateInputOpt() changed the validator's own options")
+	}
+}